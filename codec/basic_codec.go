@@ -3,9 +3,11 @@ package codec
 import (
 	"fmt"
 	"reflect"
+	"unicode/utf8"
 
 	"go.mongodb.org/mongo-driver/bson/bsoncodec"
 	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
@@ -37,6 +39,34 @@ func newProtobufBasicCodec(r *CodecsRegistry) *protobufBasicCodec {
 func (pc *protobufBasicCodec) EncodeValue(
 	ctx bsoncodec.EncodeContext, w bsonrw.ValueWriter, val protoreflect.Value,
 ) error {
+	return pc.encodeScalar(ctx, w, nil, val)
+}
+
+// encodeScalar кодирует значение val поля fd с учетом опций поля. fd может быть
+// nil, тогда используются настройки кодирования реестра, а enum'ы пишутся
+// номерами, т.к. без поля неизвестно их описание.
+func (pc *protobufBasicCodec) encodeScalar(
+	ctx bsoncodec.EncodeContext, w bsonrw.ValueWriter, fd protoreflect.FieldDescriptor,
+	val protoreflect.Value,
+) error {
+	if fd != nil && fd.Enum() != nil {
+		return pc.encodeEnum(w, fd.Enum(), val.Enum())
+	}
+	if u, ok := val.Interface().(uint64); ok {
+		return encodeUint64(w, pc.uint64Mode(fd), u)
+	}
+	if fd != nil && fd.Kind() == protoreflect.BytesKind {
+		return encodeBytes(w, fd, val.Bytes())
+	}
+	if fd != nil && fd.Kind() == protoreflect.StringKind {
+		uuid, err := isUUIDField(fd)
+		if err != nil {
+			return err
+		}
+		if uuid {
+			return encodeUUID(w, val.String())
+		}
+	}
 	encoder, err := ctx.LookupEncoder(reflect.TypeOf(val.Interface()))
 	if err != nil {
 		return err
@@ -58,9 +88,77 @@ func (pc *protobufBasicCodec) createWithCanonicalType(t reflect.Type) (val refle
 	return value, false
 }
 
+// decodeScalar декодирует значение поля fd. val - пустое значение нужного типа,
+// например, полученное из NewField. fd может быть nil.
+func (pc *protobufBasicCodec) decodeScalar(
+	ctx bsoncodec.DecodeContext, r bsonrw.ValueReader, fd protoreflect.FieldDescriptor,
+	val protoreflect.Value,
+) (protoreflect.Value, error) {
+	if fd != nil && fd.Enum() != nil {
+		n, err := pc.decodeEnum(r, fd.Enum())
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfEnum(n), nil
+	}
+	if fd != nil && isNumericKind(fd.Kind()) {
+		return pc.decodeNumeric(r, fd)
+	}
+	if fd != nil && fd.Kind() == protoreflect.BytesKind && r.Type() == bsontype.Binary {
+		data, err := decodeBytes(r)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfBytes(data), nil
+	}
+	if fd != nil && fd.Kind() == protoreflect.StringKind {
+		uuid, err := isUUIDField(fd)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		if uuid {
+			id, err := decodeUUID(r)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfString(id), nil
+		}
+	}
+	// Драйвер приводит к строкам и байтам многие BSON типы, в строгом режиме
+	// принимается только точное совпадение.
+	if fd != nil && pc.registry.UnmarshalOptions.Strict {
+		if err := checkStrictType(r.Type(), fd.Kind()); err != nil {
+			return protoreflect.Value{}, err
+		}
+	}
+	value, err := pc.DecodeValue(ctx, r, reflect.TypeOf(val.Interface()))
+	if err != nil {
+		return protoreflect.Value{}, err
+	}
+	if str, ok := value.(string); ok && pc.registry.UnmarshalOptions.Strict && !utf8.ValidString(str) {
+		return protoreflect.Value{}, fmt.Errorf("string is not valid UTF-8")
+	}
+	return protoreflect.ValueOf(value), nil
+}
+
+// checkStrictType проверяет, что BSON тип t совпадает с видом строкового или
+// байтового поля kind. UUID поля проверяются при декодировании UUID.
+func checkStrictType(t bsontype.Type, kind protoreflect.Kind) error {
+	switch {
+	case kind == protoreflect.StringKind && t != bsontype.String,
+		kind == protoreflect.BytesKind && t != bsontype.Binary:
+		return fmt.Errorf("BSON %s can't be decoded into %s in strict mode", t, kind)
+	}
+	return nil
+}
+
 func (pc *protobufBasicCodec) DecodeValue(
 	ctx bsoncodec.DecodeContext, r bsonrw.ValueReader, valType reflect.Type,
 ) (interface{}, error) {
+	// uint64 читается в любом из представлений Uint64Mode.
+	if valType == reflect.TypeOf(uint64(0)) {
+		return decodeUint64(r)
+	}
 	decoder, err := ctx.LookupDecoder(valType)
 	if err != nil {
 		return nil, err
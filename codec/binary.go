@@ -0,0 +1,116 @@
+package codec
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// uuidLen - длина UUID в байтах.
+const uuidLen = 16
+
+// binarySubtype возвращает подтип BSON Binary bytes поля fd из опции
+// (proto_bson.field).binary_subtype.
+func binarySubtype(fd pref.FieldDescriptor) (byte, error) {
+	subtype := getFieldOptions(fd).GetBinarySubtype()
+	if subtype > 0xFF {
+		return 0, fmt.Errorf("field %s has invalid binary subtype %d", fd.FullName(), subtype)
+	}
+	return byte(subtype), nil
+}
+
+// isUUIDField сообщает, размечено ли поле fd как UUID. Разметка у полей
+// других видов, кроме строковых, считается ошибкой.
+func isUUIDField(fd pref.FieldDescriptor) (bool, error) {
+	if !getFieldOptions(fd).GetUuid() {
+		return false, nil
+	}
+	if fd.Kind() != pref.StringKind {
+		return false, fmt.Errorf("field %s can't be a UUID: only string fields are supported", fd.FullName())
+	}
+	return true, nil
+}
+
+func encodeBytes(w bsonrw.ValueWriter, fd pref.FieldDescriptor, data []byte) error {
+	subtype, err := binarySubtype(fd)
+	if err != nil {
+		return err
+	}
+	return w.WriteBinaryWithSubtype(data, subtype)
+}
+
+// decodeBytes читает bytes значение из Binary любого подтипа.
+func decodeBytes(r bsonrw.ValueReader) ([]byte, error) {
+	if r.Type() != bsontype.Binary {
+		return nil, fmt.Errorf("can't decode bytes from BSON %s", r.Type())
+	}
+	data, _, err := r.ReadBinary()
+	return data, err
+}
+
+// encodeUUID пишет строку id как Binary с подтипом UUID. Пустая строка пишется
+// как есть, т.к. у незаполненного поля нет UUID.
+func encodeUUID(w bsonrw.ValueWriter, id string) error {
+	if id == "" {
+		return w.WriteString(id)
+	}
+	data, err := parseUUID(id)
+	if err != nil {
+		return err
+	}
+	return w.WriteBinaryWithSubtype(data, bsontype.BinaryUUID)
+}
+
+// decodeUUID читает UUID, сохраненный как Binary с подтипом UUID, либо
+// строкой, и возвращает его каноническую строку.
+func decodeUUID(r bsonrw.ValueReader) (string, error) {
+	switch r.Type() {
+	case bsontype.String:
+		id, err := r.ReadString()
+		if err != nil || id == "" {
+			return id, err
+		}
+		data, err := parseUUID(id)
+		if err != nil {
+			return "", err
+		}
+		return formatUUID(data), nil
+	case bsontype.Binary:
+		data, subtype, err := r.ReadBinary()
+		if err != nil {
+			return "", err
+		}
+		if subtype != bsontype.BinaryUUID || len(data) != uuidLen {
+			return "", fmt.Errorf("binary of subtype %#x and length %d is not a UUID", subtype, len(data))
+		}
+		return formatUUID(data), nil
+	}
+	return "", fmt.Errorf("can't decode UUID from BSON %s", r.Type())
+}
+
+// parseUUID разбирает UUID вида xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx, либо
+// 32 hex символа без дефисов, в любом регистре.
+func parseUUID(id string) ([]byte, error) {
+	str := id
+	if len(str) == 36 {
+		if str[8] != '-' || str[13] != '-' || str[18] != '-' || str[23] != '-' {
+			return nil, fmt.Errorf("invalid UUID %q", id)
+		}
+		str = strings.ReplaceAll(str, "-", "")
+	}
+	data, err := hex.DecodeString(str)
+	if err != nil || len(data) != uuidLen {
+		return nil, fmt.Errorf("invalid UUID %q", id)
+	}
+	return data, nil
+}
+
+// formatUUID возвращает каноническую строку UUID data.
+func formatUUID(data []byte) string {
+	str := hex.EncodeToString(data)
+	return str[:8] + "-" + str[8:12] + "-" + str[12:16] + "-" + str[16:20] + "-" + str[20:]
+}
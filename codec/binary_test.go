@@ -0,0 +1,61 @@
+package codec
+
+import (
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"google.golang.org/protobuf/proto"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestBinarySubtype(t *testing.T) {
+	assert := asrt.New(t)
+	msg := &gen.Scalars{
+		Data:      []byte{1, 2, 3},
+		UuidBytes: []byte{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00},
+	}
+
+	bsonData, err := Marshal(msg)
+	assert.Nil(err)
+	subtype, _ := bson.Raw(bsonData).Lookup("data").Binary()
+	assert.Equal(bsontype.BinaryGeneric, subtype)
+	subtype, _ = bson.Raw(bsonData).Lookup("uuid_bytes").Binary()
+	assert.Equal(bsontype.BinaryUUID, subtype)
+
+	decoded := &gen.Scalars{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(msg, decoded))
+}
+
+func TestUUID(t *testing.T) {
+	assert := asrt.New(t)
+	msg := &gen.Scalars{
+		Uuid:  "123e4567-e89b-12d3-a456-426614174000",
+		Uuids: []string{"00000000-0000-0000-0000-000000000001"},
+	}
+
+	bsonData, err := Marshal(msg)
+	assert.Nil(err)
+	subtype, data := bson.Raw(bsonData).Lookup("uuid").Binary()
+	assert.Equal(bsontype.BinaryUUID, subtype)
+	assert.Len(data, uuidLen)
+	subtype, _ = bson.Raw(bsonData).Lookup("uuids", "0").Binary()
+	assert.Equal(bsontype.BinaryUUID, subtype)
+
+	decoded := &gen.Scalars{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(msg, decoded))
+
+	// Строки в другом регистре или без дефисов приводятся к каноническому виду.
+	id, err := decodeUUID(bsonValueReader(t, "123E4567E89B12D3A456426614174000"))
+	assert.Nil(err)
+	assert.Equal(msg.Uuid, id)
+
+	_, err = Marshal(&gen.Scalars{Uuid: "not a uuid"})
+	assert.NotNil(err)
+	_, err = decodeUUID(bsonValueReader(t, []byte{1, 2, 3}))
+	assert.NotNil(err)
+}
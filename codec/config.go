@@ -1,32 +1,39 @@
 package codec
 
 import (
-	"github.com/caarlos0/env/v6"
-	"github.com/joho/godotenv"
 	"go.uber.org/zap"
 )
 
+// Config - настройки кодеков, не связанные с форматом документов. Пакет не
+// читает ни файлы, ни переменные окружения: все настройки передаются явно в
+// NewCodecsRegistry или NewProtobufMongoCodec.
 type Config struct {
-	LogLevel string `env:"LOG_LEVEL" envDefault:"info"`
-	Debug    bool   `env:"DEBUG" envDefault:"false"`
+	// Logger получает отладочные сообщения кодеков. По умолчанию сообщения
+	// никуда не пишутся.
+	Logger Logger
 }
 
-var CFG = Config{}
+// Logger - журнал, в который кодеки пишут отладочные сообщения. keysAndValues -
+// чередующиеся ключи и значения, как в zap.SugaredLogger.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+}
 
-func ParseCodecConfigFromEnv() error {
-	_ = godotenv.Load()
-	if err := env.Parse(&CFG); err != nil {
-		return err
-	}
-	InitLogger()
-	return nil
+// NewZapLogger возвращает Logger, который пишет сообщения в l.
+func NewZapLogger(l *zap.Logger) Logger {
+	return zapLogger{sugar: l.Sugar()}
 }
 
-func InitLogger() {
-	Logger, _ = zap.NewProduction(zap.WithCaller(true))
-	defer func() { _ = Logger.Sync() }()
+// zapLogger - адаптер zap.Logger к Logger.
+type zapLogger struct {
+	sugar *zap.SugaredLogger
 }
 
-func init() {
-	_ = ParseCodecConfigFromEnv()
+func (l zapLogger) Debug(msg string, keysAndValues ...interface{}) {
+	l.sugar.Debugw(msg, keysAndValues...)
 }
+
+// nopLogger - Logger, который ничего не пишет. Используется по умолчанию.
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
@@ -0,0 +1,34 @@
+package codec
+
+import (
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+// recordLogger запоминает сообщения, которые в него пишут.
+type recordLogger struct {
+	messages []string
+}
+
+func (l *recordLogger) Debug(msg string, _ ...interface{}) {
+	l.messages = append(l.messages, msg)
+}
+
+func TestConfigLogger(t *testing.T) {
+	assert := asrt.New(t)
+	bsonData, err := bson.Marshal(bson.D{{Key: "unknown", Value: 1}})
+	assert.Nil(err)
+
+	logger := &recordLogger{}
+	registry := NewBSONRegistry(NewProtobufMongoCodec(Config{Logger: logger}))
+	assert.Nil(bson.UnmarshalWithRegistry(registry, bsonData, &gen.Example{}))
+	assert.Contains(logger.messages, "Can't find field for such bson key")
+
+	// По умолчанию сообщения никуда не пишутся.
+	registry = NewBSONRegistry(NewProtobufMongoCodec(Config{}))
+	assert.Nil(bson.UnmarshalWithRegistry(registry, bsonData, &gen.Example{}))
+}
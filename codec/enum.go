@@ -0,0 +1,102 @@
+package codec
+
+import (
+	"fmt"
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// nullValueEnumName - google.protobuf.NullValue, значение которого, как и в
+// protojson, хранится как null.
+const nullValueEnumName = "google.protobuf.NullValue"
+
+// UnknownEnumPolicy определяет, что делать при декодировании значения enum'а,
+// которого нет в его описании.
+type UnknownEnumPolicy int
+
+const (
+	// UnknownEnumKeep сохраняет неизвестные номера как есть, как это делает
+	// Protobuf для открытых enum'ов. Неизвестное имя сохранить нельзя - это
+	// ошибка. Используется по умолчанию.
+	UnknownEnumKeep UnknownEnumPolicy = iota
+	// UnknownEnumError - неизвестные имена и номера приводят к ошибке.
+	UnknownEnumError
+	// UnknownEnumDefault заменяет неизвестные имена и номера значением по
+	// умолчанию, т.е. первым значением enum'а.
+	UnknownEnumDefault
+)
+
+// encodeEnum пишет значение n enum'а ed: по умолчанию - именем значения, а с
+// MarshalOptions.UseEnumNumbers - номером. Номера, у которых нет имени,
+// пишутся числом.
+func (pc *protobufBasicCodec) encodeEnum(
+	w bsonrw.ValueWriter, ed pref.EnumDescriptor, n pref.EnumNumber,
+) error {
+	if ed.FullName() == nullValueEnumName {
+		return w.WriteNull()
+	}
+	if !pc.registry.MarshalOptions.UseEnumNumbers {
+		if value := ed.Values().ByNumber(n); value != nil {
+			return w.WriteString(string(value.Name()))
+		}
+	}
+	return w.WriteInt32(int32(n))
+}
+
+// decodeEnum читает значение enum'а ed, записанное именем, либо номером.
+// Неизвестные значения обрабатываются по UnmarshalOptions.UnknownEnum.
+func (pc *protobufBasicCodec) decodeEnum(
+	r bsonrw.ValueReader, ed pref.EnumDescriptor,
+) (pref.EnumNumber, error) {
+	policy := pc.registry.UnmarshalOptions.UnknownEnum
+	switch r.Type() {
+	case bsontype.String:
+		name, err := r.ReadString()
+		if err != nil {
+			return 0, err
+		}
+		if value := ed.Values().ByName(pref.Name(name)); value != nil {
+			return value.Number(), nil
+		}
+		if policy == UnknownEnumDefault {
+			return ed.Values().Get(0).Number(), nil
+		}
+		return 0, fmt.Errorf("unknown name %q of enum %s", name, ed.FullName())
+	case bsontype.Int32, bsontype.Int64:
+		var n int64
+		if r.Type() == bsontype.Int32 {
+			i, err := r.ReadInt32()
+			if err != nil {
+				return 0, err
+			}
+			n = int64(i)
+		} else {
+			i, err := r.ReadInt64()
+			if err != nil {
+				return 0, err
+			}
+			n = i
+		}
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return 0, fmt.Errorf("number %d of enum %s overflows int32", n, ed.FullName())
+		}
+		if ed.Values().ByNumber(pref.EnumNumber(n)) != nil {
+			return pref.EnumNumber(n), nil
+		}
+		switch policy {
+		case UnknownEnumError:
+			return 0, fmt.Errorf("unknown number %d of enum %s", n, ed.FullName())
+		case UnknownEnumDefault:
+			return ed.Values().Get(0).Number(), nil
+		}
+		return pref.EnumNumber(n), nil
+	case bsontype.Null:
+		if ed.FullName() == nullValueEnumName {
+			return 0, r.ReadNull()
+		}
+	}
+	return 0, fmt.Errorf("can't decode enum %s from BSON %s", ed.FullName(), r.Type())
+}
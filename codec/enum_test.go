@@ -0,0 +1,71 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"google.golang.org/protobuf/proto"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestEnumNames(t *testing.T) {
+	assert := asrt.New(t)
+	msg := &gen.Scalars{
+		Color:    gen.Color_COLOR_RED,
+		Colors:   []gen.Color{gen.Color_COLOR_GREEN, gen.Color(7)},
+		ColorMap: map[string]gen.Color{"main": gen.Color_COLOR_GREEN},
+	}
+
+	bsonData, err := Marshal(msg)
+	assert.Nil(err)
+	doc := bson.Raw(bsonData)
+	assert.Equal("COLOR_RED", doc.Lookup("color").StringValue())
+	assert.Equal("COLOR_GREEN", doc.Lookup("colors", "0").StringValue())
+	assert.Equal(int32(7), doc.Lookup("colors", "1").Int32(), "unknown number must stay a number")
+	assert.Equal("COLOR_GREEN", doc.Lookup("color_map", "main").StringValue())
+	decoded := &gen.Scalars{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(msg, decoded))
+
+	bsonData, err = MarshalOptions{UseEnumNumbers: true}.Marshal(msg)
+	assert.Nil(err)
+	assert.Equal(bsontype.Int32, bson.Raw(bsonData).Lookup("color").Type)
+	decoded = &gen.Scalars{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(msg, decoded))
+}
+
+func TestUnknownEnumPolicy(t *testing.T) {
+	assert := asrt.New(t)
+	bsonData, err := bson.Marshal(bson.D{
+		{Key: "color", Value: int32(7)},
+		{Key: "colors", Value: bson.A{"COLOR_PURPLE", "COLOR_RED"}},
+	})
+	assert.Nil(err)
+
+	// Неизвестные номера сохраняются, а неизвестное имя - ошибка.
+	decoded := &gen.Scalars{}
+	err = Unmarshal(bsonData, decoded)
+	var decodeErr *DecodeError
+	if assert.True(errors.As(err, &decodeErr)) {
+		assert.Equal("colors.0", decodeErr.Path)
+	}
+	assert.Equal(gen.Color(7), decoded.Color)
+
+	decoded = &gen.Scalars{}
+	assert.Nil(UnmarshalOptions{UnknownEnum: UnknownEnumDefault}.Unmarshal(bsonData, decoded))
+	assert.Equal(gen.Color_COLOR_UNSPECIFIED, decoded.Color)
+	assert.Equal([]gen.Color{gen.Color_COLOR_UNSPECIFIED, gen.Color_COLOR_RED}, decoded.Colors)
+
+	r := DefaultCodecsRegistry()
+	r.UnmarshalOptions.UnknownEnum = UnknownEnumError
+	ed := gen.Color(0).Descriptor()
+	for _, value := range []interface{}{int32(7), "COLOR_PURPLE", true} {
+		_, err = r.BasicCodec.decodeEnum(bsonValueReader(t, value), ed)
+		assert.NotNil(err, value)
+	}
+}
@@ -0,0 +1,149 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.uber.org/multierr"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DecodeError - ошибка декодирования значения документа.
+type DecodeError struct {
+	// Message - полное имя сообщения, от которого отсчитывается Path.
+	Message pref.FullName
+	// Path - полный путь к значению через точку: ключи документов, индексы
+	// массивов и ключи мап, например, "projects.main" или "items.2.name".
+	Path string
+	// BSONType - BSON тип значения, которое не удалось декодировать.
+	BSONType bsontype.Type
+	// Kind - вид поля Protobuf'а, в которое декодировалось значение.
+	Kind pref.Kind
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	var b strings.Builder
+	b.WriteString("can't decode ")
+	b.WriteString(e.Path)
+	if e.Message != "" {
+		fmt.Fprintf(&b, " of %s", e.Message)
+	}
+	if e.Kind != 0 {
+		fmt.Fprintf(&b, " (%s from BSON %s)", e.Kind, e.BSONType)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// EncodeError - ошибка кодирования значения сообщения.
+type EncodeError struct {
+	// Message - полное имя сообщения, от которого отсчитывается Path.
+	Message pref.FullName
+	// Path - полный путь к значению через точку, как у DecodeError.
+	Path string
+	// Kind - вид поля Protobuf'а, значение которого не удалось закодировать.
+	Kind pref.Kind
+	Err  error
+}
+
+func (e *EncodeError) Error() string {
+	var b strings.Builder
+	b.WriteString("can't encode ")
+	b.WriteString(e.Path)
+	if e.Message != "" {
+		fmt.Fprintf(&b, " of %s", e.Message)
+	}
+	if e.Kind != 0 {
+		fmt.Fprintf(&b, " (%s)", e.Kind)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+func (e *EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// wrapDecodeError добавляет key в начало пути ошибки err, которая возникла
+// при декодировании значения BSON типа bsonType в поле fd. Ошибки, которые
+// еще не *DecodeError, оборачиваются в него. fd может быть nil.
+func wrapDecodeError(
+	key string, fd pref.FieldDescriptor, bsonType bsontype.Type, err error,
+) *DecodeError {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		wrapped := *decodeErr
+		wrapped.Path = key + "." + wrapped.Path
+		return &wrapped
+	}
+	wrapped := &DecodeError{Path: key, BSONType: bsonType, Err: err}
+	if fd != nil {
+		wrapped.Kind = fd.Kind()
+	}
+	return wrapped
+}
+
+// wrapDecodeErrors применяет wrapDecodeError к каждой ошибке, собранной в err
+// с помощью multierr. Если msg не пустое, то путь ошибок отсчитывается от
+// сообщения msg.
+func wrapDecodeErrors(
+	msg pref.FullName, key string, fd pref.FieldDescriptor, bsonType bsontype.Type, err error,
+) error {
+	var errs error
+	for _, err := range multierr.Errors(err) {
+		decodeErr := wrapDecodeError(key, fd, bsonType, err)
+		if msg != "" {
+			decodeErr.Message = msg
+		}
+		errs = multierr.Append(errs, decodeErr)
+	}
+	return errs
+}
+
+// appendDecodeError добавляет err к ошибкам декодирования errs. Если ошибки
+// собираются (UnmarshalOptions.CollectErrors), то возвращается true -
+// декодирование можно продолжить со следующего элемента, т.к. значение с
+// ошибкой было прочитано из копии (см. detachValue).
+func (r *CodecsRegistry) appendDecodeError(errs *error, err error) bool {
+	*errs = multierr.Append(*errs, err)
+	return r.UnmarshalOptions.CollectErrors
+}
+
+// detachValue возвращает читатель значения vr. Если ошибки собираются, то
+// значение целиком копируется и читается из копии: декодер, который прервался
+// посреди вложенного документа или массива, не собьет читатель родительского
+// документа, и следующие элементы будут прочитаны с правильного места.
+func (r *CodecsRegistry) detachValue(vr bsonrw.ValueReader) (bsonrw.ValueReader, error) {
+	if !r.UnmarshalOptions.CollectErrors {
+		return vr, nil
+	}
+	t, data, err := bsonrw.Copier{}.CopyValueToBytes(vr)
+	if err != nil {
+		return nil, err
+	}
+	return bsonrw.NewBSONValueReader(t, data), nil
+}
+
+// wrapEncodeError добавляет key в начало пути ошибки err, которая возникла
+// при кодировании значения поля fd, как wrapDecodeError.
+func wrapEncodeError(key string, fd pref.FieldDescriptor, err error) *EncodeError {
+	var encodeErr *EncodeError
+	if errors.As(err, &encodeErr) {
+		wrapped := *encodeErr
+		wrapped.Path = key + "." + wrapped.Path
+		return &wrapped
+	}
+	wrapped := &EncodeError{Path: key, Err: err}
+	if fd != nil {
+		wrapped.Kind = fd.Kind()
+	}
+	return wrapped
+}
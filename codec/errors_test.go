@@ -0,0 +1,173 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/multierr"
+	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestDecodeError(t *testing.T) {
+	assert := asrt.New(t)
+	bsonData, err := bson.Marshal(bson.D{
+		{Key: "string_field", Value: "a"},
+		{Key: "nested_message", Value: bson.D{{Key: "nested_int32_field", Value: true}}},
+	})
+	assert.Nil(err)
+
+	err = Unmarshal(bsonData, &gen.Example{})
+	var decodeErr *DecodeError
+	if assert.True(errors.As(err, &decodeErr)) {
+		assert.Equal(pref.FullName("Example"), decodeErr.Message)
+		assert.Equal("nested_message.nested_int32_field", decodeErr.Path)
+		assert.Equal(bsontype.Boolean, decodeErr.BSONType)
+		assert.Equal(pref.Int32Kind, decodeErr.Kind)
+		assert.EqualError(err, "can't decode nested_message.nested_int32_field of Example "+
+			"(int32 from BSON boolean): can't decode integer from BSON boolean")
+	}
+
+	bsonData, err = bson.Marshal(bson.D{{Key: "uuids", Value: bson.A{"", int32(1)}}})
+	assert.Nil(err)
+	err = Unmarshal(bsonData, &gen.Scalars{})
+	if assert.True(errors.As(err, &decodeErr)) {
+		assert.Equal(pref.FullName("gen.Scalars"), decodeErr.Message)
+		assert.Equal("uuids.1", decodeErr.Path)
+		assert.Equal(bsontype.Int32, decodeErr.BSONType)
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	assert := asrt.New(t)
+
+	_, err := Marshal(&gen.Scalars{Uuids: []string{"00112233-4455-6677-8899-aabbccddeeff", "not-a-uuid"}})
+	var encodeErr *EncodeError
+	if assert.True(errors.As(err, &encodeErr)) {
+		assert.Equal(pref.FullName("gen.Scalars"), encodeErr.Message)
+		assert.Equal("uuids.1", encodeErr.Path)
+		assert.Equal(pref.StringKind, encodeErr.Kind)
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	assert := asrt.New(t)
+	bsonData, err := bson.Marshal(bson.D{
+		{Key: "string_field", Value: "ok"},
+		{Key: "nested_message", Value: bson.D{
+			{Key: "nested_int32_field", Value: true},
+			{Key: "nested_string_field", Value: "nested"},
+		}},
+		{Key: "str_array", Value: bson.A{"a", int32(1), "c"}},
+		{Key: "projects", Value: bson.D{{Key: "dev", Value: "yes"}, {Key: "prod", Value: true}}},
+		{Key: "unknown", Value: 1},
+	})
+	assert.Nil(err)
+
+	decoded := &gen.Example{}
+	err = UnmarshalOptions{CollectErrors: true}.Unmarshal(bsonData, decoded)
+	paths := make([]string, 0)
+	for _, err := range multierr.Errors(err) {
+		var decodeErr *DecodeError
+		if assert.True(errors.As(err, &decodeErr)) {
+			assert.Equal(pref.FullName("Example"), decodeErr.Message)
+			paths = append(paths, decodeErr.Path)
+		}
+	}
+	assert.Equal([]string{"nested_message.nested_int32_field", "str_array.1", "projects.dev"}, paths)
+	assert.Equal("ok", decoded.StringField)
+	assert.Equal("nested", decoded.GetNestedMessage().GetNestedStringField())
+	assert.Equal([]string{"a", "c"}, decoded.StrArray)
+	assert.Equal(map[string]bool{"prod": true}, decoded.Projects)
+
+	// В строгом режиме собираются и ошибки неизвестных ключей.
+	err = UnmarshalOptions{CollectErrors: true, Strict: true}.Unmarshal(bsonData, &gen.Example{})
+	assert.Len(multierr.Errors(err), 4)
+}
+
+func TestCollectErrorsSiblings(t *testing.T) {
+	cases := []struct {
+		name  string
+		doc   bson.D
+		msg   proto.Message
+		path  string
+		check func(assert *asrt.Assertions, msg proto.Message)
+	}{
+		{
+			name: "timestamp",
+			doc: bson.D{
+				{Key: "ts", Value: bson.D{{Key: "seconds", Value: "x"}, {Key: "nanos", Value: int32(1)}}},
+				{Key: "string_field", Value: "ok"},
+			},
+			msg:  &gen.Example{},
+			path: "ts",
+			check: func(assert *asrt.Assertions, msg proto.Message) {
+				assert.Equal("ok", msg.(*gen.Example).StringField)
+			},
+		},
+		{
+			name: "duration",
+			doc: bson.D{
+				{Key: "duration", Value: bson.D{{Key: "seconds", Value: true}, {Key: "nanos", Value: int32(1)}}},
+				{Key: "int32_value", Value: int32(7)},
+			},
+			msg:  &gen.WellKnownTypes{},
+			path: "duration",
+			check: func(assert *asrt.Assertions, msg proto.Message) {
+				assert.Equal(int32(7), msg.(*gen.WellKnownTypes).GetInt32Value().GetValue())
+			},
+		},
+		{
+			name: "struct",
+			doc: bson.D{
+				{Key: "struct", Value: bson.D{
+					{Key: "re", Value: primitive.Regex{Pattern: "a"}},
+					{Key: "after", Value: "x"},
+				}},
+				{Key: "int32_value", Value: int32(7)},
+			},
+			msg:  &gen.WellKnownTypes{},
+			path: "struct.re",
+			check: func(assert *asrt.Assertions, msg proto.Message) {
+				assert.Equal(int32(7), msg.(*gen.WellKnownTypes).GetInt32Value().GetValue())
+			},
+		},
+		{
+			name: "wrapped oneof",
+			doc: bson.D{
+				{Key: "example_oneof", Value: bson.D{
+					{Key: "int32_field", Value: int32(1)},
+					{Key: "int64_field", Value: int64(2)},
+					{Key: "one_string_field", Value: "z"},
+				}},
+				{Key: "string_field", Value: "ok"},
+			},
+			msg:  &gen.Example{},
+			path: "example_oneof.int64_field",
+			check: func(assert *asrt.Assertions, msg proto.Message) {
+				assert.Equal("ok", msg.(*gen.Example).StringField)
+			},
+		},
+	}
+	for _, c := range cases {
+		assert := asrt.New(t)
+
+		bsonData, err := bson.Marshal(c.doc)
+		assert.Nil(err)
+		options := UnmarshalOptions{CollectErrors: true, NonJSONTypes: NonJSONError}
+		errs := multierr.Errors(options.Unmarshal(bsonData, c.msg))
+		if assert.Len(errs, 1, c.name) {
+			var decodeErr *DecodeError
+			if assert.True(errors.As(errs[0], &decodeErr), c.name) {
+				assert.Equal(c.path, decodeErr.Path, c.name)
+			}
+		}
+		c.check(assert, c.msg)
+	}
+}
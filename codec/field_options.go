@@ -0,0 +1,82 @@
+package codec
+
+import (
+	"fmt"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	protobson "bitbucket.org/entrlcom/proto-mongo/proto_bson"
+)
+
+// IDKey - ключ идентификатора документа в Mongo.
+const IDKey = "_id"
+
+// getFieldOptions возвращает опции (proto_bson.field) поля, либо nil, если поле
+// ими не размечено. Геттеры сгенерированного типа корректно работают и с nil.
+// Для ключа и значения мапы возвращаются опции самой мапы.
+func getFieldOptions(field pref.FieldDescriptor) *protobson.FieldOptions {
+	field = optionsField(field)
+	// Дескрипторы неизменяемы, поэтому опции достаточно достать один раз.
+	if cached, ok := fieldOptionsCache.Load(field); ok {
+		return cached.(*protobson.FieldOptions)
+	}
+	var fieldOpts *protobson.FieldOptions
+	if opts, ok := field.Options().(*descriptorpb.FieldOptions); ok && opts != nil {
+		fieldOpts, _ = proto.GetExtension(opts, protobson.E_Field).(*protobson.FieldOptions)
+	}
+	fieldOptionsCache.Store(field, fieldOpts)
+	return fieldOpts
+}
+
+// fieldOptionsCache - опции (proto_bson.field) по дескрипторам полей.
+var fieldOptionsCache sync.Map
+
+// getMessageOptions возвращает опции (proto_bson.message) сообщения, либо nil,
+// если сообщение ими не размечено.
+func getMessageOptions(md pref.MessageDescriptor) *protobson.MessageOptions {
+	opts, ok := md.Options().(*descriptorpb.MessageOptions)
+	if !ok || opts == nil {
+		return nil
+	}
+	msgOpts, _ := proto.GetExtension(opts, protobson.E_Message).(*protobson.MessageOptions)
+	return msgOpts
+}
+
+// isInlineField сообщает, нужно ли писать поля вложенного сообщения field прямо
+// в документ родителя. Разметка inline у полей других видов считается ошибкой.
+func isInlineField(field pref.FieldDescriptor) (bool, error) {
+	if !getFieldOptions(field).GetInline() {
+		return false, nil
+	}
+	if field.Message() == nil || field.IsList() || field.IsMap() {
+		return false, fmt.Errorf(
+			"field %s can't be inlined: only singular message fields are supported",
+			field.FullName(),
+		)
+	}
+	return true, nil
+}
+
+// optionsField возвращает поле, которое несет опции для field. У ключа и
+// значения мапы своих опций нет, они задаются на поле-мапе, которое находится
+// по сообщению-записи мапы.
+func optionsField(field pref.FieldDescriptor) pref.FieldDescriptor {
+	entry := field.ContainingMessage()
+	if entry == nil || !entry.IsMapEntry() {
+		return field
+	}
+	parent, ok := entry.Parent().(pref.MessageDescriptor)
+	if !ok {
+		return field
+	}
+	fields := parent.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if mapField := fields.Get(i); mapField.IsMap() && mapField.Message().FullName() == entry.FullName() {
+			return mapField
+		}
+	}
+	return field
+}
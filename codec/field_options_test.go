@@ -0,0 +1,76 @@
+package codec
+
+import (
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"google.golang.org/protobuf/proto"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestFieldOptions(t *testing.T) {
+	assert := asrt.New(t)
+	annotated := &gen.AnnotatedMessage{
+		Id:          "42",
+		DisplayName: "John Doe",
+		Password:    "secret",
+		Audit:       &gen.Audit{CreatedBy: "admin", Revision: 3},
+	}
+
+	bsonData, err := MarshalOptions{FieldNaming: JSONNames}.Marshal(annotated)
+	assert.Nil(err)
+	elements, err := bson.Raw(bsonData).Elements()
+	assert.Nil(err)
+	keys := make([]string, 0, len(elements))
+	for _, element := range elements {
+		keys = append(keys, element.Key())
+	}
+	assert.Equal([]string{"_id", "name", "createdBy", "revision"}, keys)
+
+	decoded := &gen.AnnotatedMessage{}
+	err = UnmarshalOptions{FieldNaming: JSONNames}.Unmarshal(bsonData, decoded)
+	assert.Nil(err)
+	annotated.Password = ""
+	assert.True(proto.Equal(annotated, decoded), "decoded message must be equal to the original")
+}
+
+func TestObjectID(t *testing.T) {
+	assert := asrt.New(t)
+
+	for _, id := range []string{"5f1d7f0c8e3b4a2d9c6b1a0e", "5F1D7F0C8E3B4A2D9C6B1A0E", "42"} {
+		bsonData, err := Marshal(&gen.AnnotatedMessage{Id: id})
+		assert.Nil(err)
+		value, err := bson.Raw(bsonData).LookupErr(IDKey)
+		assert.Nil(err)
+		if id == "5f1d7f0c8e3b4a2d9c6b1a0e" {
+			assert.Equal(bsontype.ObjectID, value.Type, "canonical hex id must be stored as ObjectID")
+		} else {
+			assert.Equal(bsontype.String, value.Type)
+		}
+
+		decoded := &gen.AnnotatedMessage{}
+		assert.Nil(Unmarshal(bsonData, decoded))
+		assert.Equal(id, decoded.Id)
+	}
+}
+
+func TestWithIDField(t *testing.T) {
+	assert := asrt.New(t)
+	naming := WithIDField("string_field", JSONNames)
+	msg := &gen.Example{StringField: "5f1d7f0c8e3b4a2d9c6b1a0e", EnumField: gen.ExampleEnum_VAL_1}
+
+	bsonData, err := MarshalOptions{FieldNaming: naming}.Marshal(msg)
+	assert.Nil(err)
+	value, err := bson.Raw(bsonData).LookupErr(IDKey)
+	assert.Nil(err)
+	assert.Equal(bsontype.ObjectID, value.Type)
+	_, err = bson.Raw(bsonData).LookupErr("enumField")
+	assert.Nil(err)
+
+	decoded := &gen.Example{}
+	assert.Nil(UnmarshalOptions{FieldNaming: naming}.Unmarshal(bsonData, decoded))
+	assert.Equal(msg.StringField, decoded.StringField)
+}
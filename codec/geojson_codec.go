@@ -0,0 +1,436 @@
+package codec
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+
+	protobson "bitbucket.org/entrlcom/proto-mongo/proto_bson"
+)
+
+const (
+	geoJSONTypeKey        = "type"
+	geoJSONCoordinatesKey = "coordinates"
+
+	geoJSONPoint      = "Point"
+	geoJSONLineString = "LineString"
+	geoJSONPolygon    = "Polygon"
+
+	latitudeFieldName  = "latitude"
+	longitudeFieldName = "longitude"
+
+	// minLineStringLen - минимальное число точек линии.
+	minLineStringLen = 2
+	// minPolygonRingLen - минимальное число точек замкнутого контура.
+	minPolygonRingLen = 4
+
+	maxLatitude  = 90
+	maxLongitude = 180
+)
+
+// geoShape возвращает вид GeoJSON фигуры для сообщения md: google.type.LatLng
+// всегда точка, остальные сообщения - по опции (proto_bson.message).geo_shape.
+func geoShape(md pref.MessageDescriptor) protobson.GeoShape {
+	if md.FullName() == ProtobufKindLatLng {
+		return protobson.GeoShape_GEO_SHAPE_POINT
+	}
+	return getMessageOptions(md).GetGeoShape()
+}
+
+// geoPointFields возвращает поля latitude и longitude сообщения-точки md.
+func geoPointFields(md pref.MessageDescriptor) (lat, lng pref.FieldDescriptor, err error) {
+	fields := md.Fields()
+	lat, lng = fields.ByName(latitudeFieldName), fields.ByName(longitudeFieldName)
+	for _, field := range []pref.FieldDescriptor{lat, lng} {
+		if field == nil || field.Kind() != pref.DoubleKind || field.Cardinality() == pref.Repeated {
+			return nil, nil, fmt.Errorf(
+				"message %s can't be a GeoJSON point: it must have double fields %s and %s",
+				md.FullName(), latitudeFieldName, longitudeFieldName,
+			)
+		}
+	}
+	return lat, lng, nil
+}
+
+// geoPointsField возвращает единственное repeated поле точек линии или
+// полигона md.
+func geoPointsField(md pref.MessageDescriptor) (pref.FieldDescriptor, error) {
+	fields := md.Fields()
+	if fields.Len() == 1 {
+		field := fields.Get(0)
+		if field.IsList() && field.Message() != nil {
+			if _, _, err := geoPointFields(field.Message()); err == nil {
+				return field, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf(
+		"message %s can't be a GeoJSON shape: it must have a single repeated field of points",
+		md.FullName(),
+	)
+}
+
+// protobufGeoJSONCodec кодирует/декодирует google.type.LatLng и сообщения,
+// размеченные опцией (proto_bson.message).geo_shape, в GeoJSON фигуры:
+// {type: "Point", coordinates: [lng, lat]}, LineString и Polygon, так что по
+// ним работают индексы 2dsphere и запросы $near. Декодирование точки понимает
+// и прежнее представление {latitude, longitude}.
+type protobufGeoJSONCodec struct {
+	registry *CodecsRegistry
+}
+
+func newProtobufGeoJSONCodec(r *CodecsRegistry) *protobufGeoJSONCodec {
+	return &protobufGeoJSONCodec{
+		registry: r,
+	}
+}
+
+func (pc *protobufGeoJSONCodec) EncodeValue(
+	_ bsoncodec.EncodeContext, w bsonrw.ValueWriter, val pref.Value,
+) error {
+	msg := val.Message()
+	md := msg.Descriptor()
+
+	var geoType string
+	var writeCoordinates func(bsonrw.ValueWriter) error
+	switch geoShape(md) {
+	case protobson.GeoShape_GEO_SHAPE_POINT:
+		lat, lng, err := geoPointFields(md)
+		if err != nil {
+			return err
+		}
+		geoType = geoJSONPoint
+		writeCoordinates = func(vw bsonrw.ValueWriter) error {
+			return writeGeoPosition(vw, msg.Get(lat).Float(), msg.Get(lng).Float())
+		}
+	case protobson.GeoShape_GEO_SHAPE_LINE_STRING, protobson.GeoShape_GEO_SHAPE_POLYGON:
+		field, err := geoPointsField(md)
+		if err != nil {
+			return err
+		}
+		points := msg.Get(field).List()
+		geoType = geoJSONLineString
+		writeCoordinates = func(vw bsonrw.ValueWriter) error {
+			return writeGeoPositions(vw, points)
+		}
+		if geoShape(md) == protobson.GeoShape_GEO_SHAPE_POLYGON {
+			geoType = geoJSONPolygon
+			writeCoordinates = func(vw bsonrw.ValueWriter) error {
+				aw, err := vw.WriteArray()
+				if err != nil {
+					return err
+				}
+				ringWriter, err := aw.WriteArrayElement()
+				if err != nil {
+					return err
+				}
+				if err = writeGeoPositions(ringWriter, points); err != nil {
+					return err
+				}
+				return aw.WriteArrayEnd()
+			}
+		}
+	default:
+		return fmt.Errorf("message %s is not a GeoJSON shape", md.FullName())
+	}
+	if err := validateGeoShape(msg, geoShape(md)); err != nil {
+		return fmt.Errorf("message %s: %w", md.FullName(), err)
+	}
+
+	dw, err := w.WriteDocument()
+	if err != nil {
+		return err
+	}
+	vw, err := dw.WriteDocumentElement(geoJSONTypeKey)
+	if err != nil {
+		return err
+	}
+	if err = vw.WriteString(geoType); err != nil {
+		return err
+	}
+	if vw, err = dw.WriteDocumentElement(geoJSONCoordinatesKey); err != nil {
+		return err
+	}
+	if err = writeCoordinates(vw); err != nil {
+		return err
+	}
+	return dw.WriteDocumentEnd()
+}
+
+func (pc *protobufGeoJSONCodec) DecodeValue(
+	_ bsoncodec.DecodeContext, r bsonrw.ValueReader, val pref.Value,
+) error {
+	msg := val.Message()
+	md := msg.Descriptor()
+	shape := geoShape(md)
+
+	var expectedType string
+	var readCoordinates func(bsonrw.ValueReader) error
+	var lat, lng pref.FieldDescriptor
+	switch shape {
+	case protobson.GeoShape_GEO_SHAPE_POINT:
+		var err error
+		if lat, lng, err = geoPointFields(md); err != nil {
+			return err
+		}
+		expectedType = geoJSONPoint
+		readCoordinates = func(vr bsonrw.ValueReader) error {
+			return readGeoPosition(vr, msg, lat, lng)
+		}
+	case protobson.GeoShape_GEO_SHAPE_LINE_STRING, protobson.GeoShape_GEO_SHAPE_POLYGON:
+		field, err := geoPointsField(md)
+		if err != nil {
+			return err
+		}
+		points := msg.Mutable(field).List()
+		expectedType = geoJSONLineString
+		readCoordinates = func(vr bsonrw.ValueReader) error {
+			return readGeoPositions(vr, points)
+		}
+		if shape == protobson.GeoShape_GEO_SHAPE_POLYGON {
+			expectedType = geoJSONPolygon
+			readCoordinates = func(vr bsonrw.ValueReader) error {
+				return readGeoPolygon(vr, points)
+			}
+		}
+	default:
+		return fmt.Errorf("message %s is not a GeoJSON shape", md.FullName())
+	}
+
+	dr, err := r.ReadDocument()
+	if err != nil {
+		return err
+	}
+	naming := pc.registry.UnmarshalOptions.FieldNaming
+	for {
+		key, vr, err := dr.ReadElement()
+		if err == bsonrw.ErrEOD {
+			break
+		} else if err != nil {
+			return err
+		}
+		switch {
+		case key == geoJSONTypeKey:
+			geoType, err := vr.ReadString()
+			if err != nil {
+				return err
+			}
+			if geoType != expectedType {
+				return fmt.Errorf("can't decode GeoJSON %s into %s", geoType, md.FullName())
+			}
+		case key == geoJSONCoordinatesKey:
+			if err = readCoordinates(vr); err != nil {
+				return err
+			}
+		case lat != nil && key == fieldKey(naming, lat):
+			f, err := readFloat(vr, false, 64)
+			if err != nil {
+				return err
+			}
+			msg.Set(lat, pref.ValueOfFloat64(f))
+		case lng != nil && key == fieldKey(naming, lng):
+			f, err := readFloat(vr, false, 64)
+			if err != nil {
+				return err
+			}
+			msg.Set(lng, pref.ValueOfFloat64(f))
+		default:
+			if err = vr.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+	if err = validateGeoShape(msg, shape); err != nil {
+		return fmt.Errorf("message %s: %w", md.FullName(), err)
+	}
+	return nil
+}
+
+// validateGeoShape проверяет фигуру msg вида shape так же, как индекс
+// 2dsphere: долгота в пределах [-180, 180], широта - [-90, 90], в линии не
+// меньше двух точек, а контур полигона замкнут. Проверка выполняется и при
+// кодировании, и после декодирования, чтобы ошибку находил кодек, а не сервер
+// при вставке или построении индекса.
+func validateGeoShape(msg pref.Message, shape protobson.GeoShape) error {
+	switch shape {
+	case protobson.GeoShape_GEO_SHAPE_POINT:
+		return validateGeoPoint(msg)
+	case protobson.GeoShape_GEO_SHAPE_LINE_STRING, protobson.GeoShape_GEO_SHAPE_POLYGON:
+		field, err := geoPointsField(msg.Descriptor())
+		if err != nil {
+			return err
+		}
+		points := msg.Get(field).List()
+		for i := 0; i < points.Len(); i++ {
+			if err = validateGeoPoint(points.Get(i).Message()); err != nil {
+				return fmt.Errorf("point %d: %w", i, err)
+			}
+		}
+		if shape == protobson.GeoShape_GEO_SHAPE_POLYGON {
+			return validateGeoRing(points)
+		}
+		if points.Len() < minLineStringLen {
+			return fmt.Errorf("line must have at least %d points, got %d", minLineStringLen, points.Len())
+		}
+	}
+	return nil
+}
+
+// validateGeoPoint проверяет, что координаты точки msg лежат в допустимых
+// пределах.
+func validateGeoPoint(msg pref.Message) error {
+	lat, lng, err := geoPointFields(msg.Descriptor())
+	if err != nil {
+		return err
+	}
+	latitude, longitude := msg.Get(lat).Float(), msg.Get(lng).Float()
+	// Сравнения записаны так, чтобы NaN тоже был ошибкой.
+	if !(latitude >= -maxLatitude && latitude <= maxLatitude) {
+		return fmt.Errorf("latitude %v is out of range [-%d, %d]", latitude, maxLatitude, maxLatitude)
+	}
+	if !(longitude >= -maxLongitude && longitude <= maxLongitude) {
+		return fmt.Errorf("longitude %v is out of range [-%d, %d]", longitude, maxLongitude, maxLongitude)
+	}
+	return nil
+}
+
+// validateGeoRing проверяет, что точки points образуют замкнутый контур, как
+// требует GeoJSON.
+func validateGeoRing(points pref.List) error {
+	if points.Len() < minPolygonRingLen {
+		return fmt.Errorf("polygon ring must have at least %d points, got %d", minPolygonRingLen, points.Len())
+	}
+	first, last := points.Get(0).Message(), points.Get(points.Len()-1).Message()
+	lat, lng, err := geoPointFields(first.Descriptor())
+	if err != nil {
+		return err
+	}
+	if first.Get(lat).Float() != last.Get(lat).Float() || first.Get(lng).Float() != last.Get(lng).Float() {
+		return fmt.Errorf("polygon ring must be closed: the first and the last points differ")
+	}
+	return nil
+}
+
+// writeGeoPosition пишет GeoJSON позицию [lng, lat].
+func writeGeoPosition(w bsonrw.ValueWriter, lat, lng float64) error {
+	aw, err := w.WriteArray()
+	if err != nil {
+		return err
+	}
+	for _, coordinate := range []float64{lng, lat} {
+		vw, err := aw.WriteArrayElement()
+		if err != nil {
+			return err
+		}
+		if err = vw.WriteDouble(coordinate); err != nil {
+			return err
+		}
+	}
+	return aw.WriteArrayEnd()
+}
+
+// writeGeoPositions пишет массив позиций точек points.
+func writeGeoPositions(w bsonrw.ValueWriter, points pref.List) error {
+	aw, err := w.WriteArray()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < points.Len(); i++ {
+		point := points.Get(i).Message()
+		lat, lng, err := geoPointFields(point.Descriptor())
+		if err != nil {
+			return err
+		}
+		vw, err := aw.WriteArrayElement()
+		if err != nil {
+			return err
+		}
+		if err = writeGeoPosition(vw, point.Get(lat).Float(), point.Get(lng).Float()); err != nil {
+			return err
+		}
+	}
+	return aw.WriteArrayEnd()
+}
+
+// readGeoPosition читает GeoJSON позицию [lng, lat] в поля lat и lng msg.
+// Высота, если она есть, пропускается.
+func readGeoPosition(r bsonrw.ValueReader, msg pref.Message, lat, lng pref.FieldDescriptor) error {
+	ar, err := r.ReadArray()
+	if err != nil {
+		return err
+	}
+	var coordinates []float64
+	for {
+		vr, err := ar.ReadValue()
+		if err == bsonrw.ErrEOA {
+			break
+		} else if err != nil {
+			return err
+		}
+		f, err := readFloat(vr, false, 64)
+		if err != nil {
+			return err
+		}
+		coordinates = append(coordinates, f)
+	}
+	if len(coordinates) < 2 {
+		return fmt.Errorf("GeoJSON position must have at least 2 coordinates, got %d", len(coordinates))
+	}
+	msg.Set(lng, pref.ValueOfFloat64(coordinates[0]))
+	msg.Set(lat, pref.ValueOfFloat64(coordinates[1]))
+	return nil
+}
+
+// readGeoPositions читает массив позиций и добавляет точки в список points.
+func readGeoPositions(r bsonrw.ValueReader, points pref.List) error {
+	ar, err := r.ReadArray()
+	if err != nil {
+		return err
+	}
+	for {
+		vr, err := ar.ReadValue()
+		if err == bsonrw.ErrEOA {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		point := points.NewElement()
+		lat, lng, err := geoPointFields(point.Message().Descriptor())
+		if err != nil {
+			return err
+		}
+		if err = readGeoPosition(vr, point.Message(), lat, lng); err != nil {
+			return err
+		}
+		points.Append(point)
+	}
+}
+
+// readGeoPolygon читает координаты полигона. Поддерживается только внешний
+// контур, полигоны с дырами - ошибка.
+func readGeoPolygon(r bsonrw.ValueReader, points pref.List) error {
+	ar, err := r.ReadArray()
+	if err != nil {
+		return err
+	}
+	for rings := 0; ; rings++ {
+		vr, err := ar.ReadValue()
+		if err == bsonrw.ErrEOA {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if rings > 0 {
+			return fmt.Errorf("GeoJSON polygons with holes are not supported")
+		}
+		if vr.Type() != bsontype.Array {
+			return fmt.Errorf("GeoJSON polygon ring must be an array, got %s", vr.Type())
+		}
+		if err = readGeoPositions(vr, points); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package codec
+
+import (
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestLatLng(t *testing.T) {
+	assert := asrt.New(t)
+	fd := googleTypeFile(t)
+	spotDesc := fd.Messages().ByName("Spot")
+	latLngDesc := fd.Messages().ByName("LatLng")
+
+	location := dynamicpb.NewMessage(latLngDesc)
+	location.Set(latLngDesc.Fields().ByName("latitude"), pref.ValueOfFloat64(55.75))
+	location.Set(latLngDesc.Fields().ByName("longitude"), pref.ValueOfFloat64(37.62))
+	spot := dynamicpb.NewMessage(spotDesc)
+	spot.Set(spotDesc.Fields().ByName("location"), pref.ValueOfMessage(location))
+
+	bsonData, err := Marshal(spot)
+	assert.Nil(err)
+	doc := bson.Raw(bsonData)
+	assert.Equal("Point", doc.Lookup("location", "type").StringValue())
+	assert.Equal(37.62, doc.Lookup("location", "coordinates", "0").Double())
+	assert.Equal(55.75, doc.Lookup("location", "coordinates", "1").Double())
+
+	decoded := dynamicpb.NewMessage(spotDesc)
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(location, decoded.Get(spotDesc.Fields().ByName("location")).Message().Interface()))
+
+	// Прежнее представление {latitude, longitude} тоже читается.
+	bsonData, err = bson.Marshal(bson.M{"location": bson.M{"latitude": 55.75, "longitude": 37.62}})
+	assert.Nil(err)
+	decoded = dynamicpb.NewMessage(spotDesc)
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(location, decoded.Get(spotDesc.Fields().ByName("location")).Message().Interface()))
+}
+
+func TestGeoShapes(t *testing.T) {
+	assert := asrt.New(t)
+	a, b, c := &gen.Location{Latitude: 1, Longitude: 2}, &gen.Location{Latitude: 3, Longitude: 4},
+		&gen.Location{Latitude: 5, Longitude: 2}
+	place := &gen.Place{
+		Location: a,
+		Route:    &gen.Route{Points: []*gen.Location{a, b}},
+		Area:     &gen.Area{Vertices: []*gen.Location{a, b, c, a}},
+	}
+
+	bsonData, err := Marshal(place)
+	assert.Nil(err)
+	doc := bson.Raw(bsonData)
+	assert.Equal("Point", doc.Lookup("location", "type").StringValue())
+	assert.Equal("LineString", doc.Lookup("route", "type").StringValue())
+	assert.Equal(4.0, doc.Lookup("route", "coordinates", "1", "0").Double())
+	assert.Equal("Polygon", doc.Lookup("area", "type").StringValue())
+	assert.Equal(5.0, doc.Lookup("area", "coordinates", "0", "2", "1").Double())
+
+	decoded := &gen.Place{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(place, decoded))
+
+	_, err = Marshal(&gen.Place{Area: &gen.Area{Vertices: []*gen.Location{a, b, c, b}}})
+	assert.NotNil(err, "polygon ring must be closed")
+}
+
+func TestGeoShapesValidation(t *testing.T) {
+	assert := asrt.New(t)
+	point := func(lng, lat float64) bson.A { return bson.A{lng, lat} }
+	tests := []struct {
+		name  string
+		place bson.M
+	}{
+		{name: "latitude", place: bson.M{"location": bson.M{"type": "Point", "coordinates": point(2, 91)}}},
+		{name: "longitude", place: bson.M{"location": bson.M{"type": "Point", "coordinates": point(-181, 1)}}},
+		{name: "legacy latitude", place: bson.M{"location": bson.M{"latitude": -90.5, "longitude": 0.0}}},
+		{name: "short line", place: bson.M{"route": bson.M{"type": "LineString", "coordinates": bson.A{point(2, 1)}}}},
+		{name: "line point", place: bson.M{"route": bson.M{"type": "LineString", "coordinates": bson.A{
+			point(2, 1), point(200, 1),
+		}}}},
+		{name: "open ring", place: bson.M{"area": bson.M{"type": "Polygon", "coordinates": bson.A{bson.A{
+			point(2, 1), point(4, 3), point(2, 5), point(4, 3),
+		}}}}},
+		{name: "short ring", place: bson.M{"area": bson.M{"type": "Polygon", "coordinates": bson.A{bson.A{
+			point(2, 1), point(4, 3), point(2, 1),
+		}}}}},
+	}
+	for _, test := range tests {
+		bsonData, err := bson.Marshal(test.place)
+		assert.Nil(err)
+		assert.NotNil(Unmarshal(bsonData, &gen.Place{}), test.name)
+	}
+
+	_, err := Marshal(&gen.Place{Location: &gen.Location{Latitude: 91}})
+	assert.NotNil(err, "latitude must be checked on encode")
+	_, err = Marshal(&gen.Place{Route: &gen.Route{Points: []*gen.Location{{Latitude: 1}}}})
+	assert.NotNil(err, "line must have at least 2 points")
+}
@@ -0,0 +1,240 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Ключи документа, в который кодируется google.protobuf.Any.
+const (
+	// anyTypeKey - URL типа вложенного сообщения, как в protojson.
+	anyTypeKey = "@type"
+	// anyValueKey - значение вложенного сообщения, у которого есть собственный
+	// кодек, например, google.protobuf.Timestamp.
+	anyValueKey = "value"
+	// anyRawTypeURLKey и anyRawValueKey - ключи запасной, двоичной формы для
+	// типов, которые не удалось найти в реестре типов.
+	anyRawTypeURLKey = "type_url"
+	anyRawValueKey   = "value"
+)
+
+// Поля google.protobuf.Any.
+const (
+	anyTypeURLFieldName = "type_url"
+	anyValueFieldName   = "value"
+)
+
+// TypeResolver ищет типы сообщений по URL из google.protobuf.Any. Ему
+// удовлетворяет protoregistry.Types, в том числе protoregistry.GlobalTypes.
+type TypeResolver interface {
+	FindMessageByURL(url string) (protoreflect.MessageType, error)
+}
+
+// resolverOrDefault возвращает resolver, либо глобальный реестр типов, если
+// он не задан в настройках.
+func resolverOrDefault(resolver TypeResolver) TypeResolver {
+	if resolver == nil {
+		return protoregistry.GlobalTypes
+	}
+	return resolver
+}
+
+// protobufAnyCodec кодирует google.protobuf.Any в документ
+// {"@type": url, ...поля вложенного сообщения}, используя для вложенного
+// сообщения те же кодеки, что и для остальных. Если у вложенного сообщения есть
+// собственный кодек, то оно пишется под ключом "value". Если тип не найден в
+// TypeResolver, то Any хранится в двоичной форме {type_url, value}. Any
+// обрабатывается через protoreflect, поэтому подходят и динамические (dynamicpb)
+// сообщения.
+type protobufAnyCodec struct {
+	registry *CodecsRegistry
+}
+
+func newProtobufAnyCodec(r *CodecsRegistry) *protobufAnyCodec {
+	return &protobufAnyCodec{
+		registry: r,
+	}
+}
+
+func (pc *protobufAnyCodec) EncodeValue(
+	ctx bsoncodec.EncodeContext, w bsonrw.ValueWriter, val protoreflect.Value,
+) error {
+	typeURL, anyValue := getAny(val.Message())
+	dw, err := w.WriteDocument()
+	if err != nil {
+		return err
+	}
+	// Пустой Any кодируется пустым документом.
+	if typeURL == "" && len(anyValue) == 0 {
+		return dw.WriteDocumentEnd()
+	}
+
+	resolver := resolverOrDefault(pc.registry.MarshalOptions.Resolver)
+	msgType, err := resolver.FindMessageByURL(typeURL)
+	if errors.Is(err, protoregistry.NotFound) {
+		if err = pc.encodeRaw(dw, typeURL, anyValue); err != nil {
+			return err
+		}
+		return dw.WriteDocumentEnd()
+	} else if err != nil {
+		return err
+	}
+	msg := msgType.New()
+	if err = proto.Unmarshal(anyValue, msg.Interface()); err != nil {
+		return fmt.Errorf("can't unmarshal %s from google.protobuf.Any: %w", typeURL, err)
+	}
+
+	vw, err := dw.WriteDocumentElement(anyTypeKey)
+	if err != nil {
+		return err
+	}
+	if err = vw.WriteString(typeURL); err != nil {
+		return err
+	}
+	// Поля обычного сообщения пишутся прямо в документ Any.
+	codec, ok := pc.registry.GetCodecForMessage(msg.Descriptor())
+	if !ok {
+		return fmt.Errorf("can't find codec for %s", msg.Descriptor().FullName())
+	}
+	if msgCodec, ok := codec.(*protobufMessageCodec); ok {
+		if err = msgCodec.encodeFields(ctx, dw, msg); err != nil {
+			return err
+		}
+		return dw.WriteDocumentEnd()
+	}
+	if vw, err = dw.WriteDocumentElement(anyValueKey); err != nil {
+		return err
+	}
+	if err = codec.EncodeValue(ctx, vw, protoreflect.ValueOfMessage(msg)); err != nil {
+		return err
+	}
+	return dw.WriteDocumentEnd()
+}
+
+// encodeRaw пишет Any в двоичной форме {type_url, value}.
+func (pc *protobufAnyCodec) encodeRaw(dw bsonrw.DocumentWriter, typeURL string, value []byte) error {
+	vw, err := dw.WriteDocumentElement(anyRawTypeURLKey)
+	if err != nil {
+		return err
+	}
+	if err = vw.WriteString(typeURL); err != nil {
+		return err
+	}
+	if vw, err = dw.WriteDocumentElement(anyRawValueKey); err != nil {
+		return err
+	}
+	return vw.WriteBinary(value)
+}
+
+func (pc *protobufAnyCodec) DecodeValue(
+	ctx bsoncodec.DecodeContext, r bsonrw.ValueReader, val protoreflect.Value,
+) error {
+	anyMsg := val.Message()
+	// Документ читается целиком, т.к. ключ "@type" не обязательно идет первым.
+	if r.Type() != bsontype.EmbeddedDocument {
+		return fmt.Errorf("can't decode google.protobuf.Any from BSON %s", r.Type())
+	}
+	_, data, err := bsonrw.Copier{}.CopyValueToBytes(r)
+	if err != nil {
+		return err
+	}
+	doc := bson.Raw(data)
+
+	typeValue, err := doc.LookupErr(anyTypeKey)
+	if err != nil {
+		return pc.decodeRaw(doc, anyMsg)
+	}
+	typeURL, ok := typeValue.StringValueOK()
+	if !ok {
+		return fmt.Errorf("google.protobuf.Any %q must be a string", anyTypeKey)
+	}
+	resolver := resolverOrDefault(pc.registry.UnmarshalOptions.Resolver)
+	msgType, err := resolver.FindMessageByURL(typeURL)
+	if err != nil {
+		return fmt.Errorf("can't resolve google.protobuf.Any type %q: %w", typeURL, err)
+	}
+	msg := msgType.New()
+	codec, ok := pc.registry.GetCodecForMessage(msg.Descriptor())
+	if !ok {
+		return fmt.Errorf("can't find codec for %s", msg.Descriptor().FullName())
+	}
+
+	var reader bsonrw.ValueReader
+	if _, ok = codec.(*protobufMessageCodec); ok {
+		// Обычному сообщению достаются все элементы документа, кроме "@type".
+		elements, err := doc.Elements()
+		if err != nil {
+			return err
+		}
+		fields := make([][]byte, 0, len(elements))
+		for _, element := range elements {
+			if element.Key() != anyTypeKey {
+				fields = append(fields, element)
+			}
+		}
+		reader = bsonrw.NewBSONDocumentReader(bsoncore.BuildDocument(nil, fields...))
+	} else {
+		value, err := doc.LookupErr(anyValueKey)
+		if err != nil {
+			return fmt.Errorf("google.protobuf.Any of type %q has no %q", typeURL, anyValueKey)
+		}
+		reader = bsonrw.NewBSONValueReader(value.Type, value.Value)
+	}
+	if err = codec.DecodeValue(ctx, reader, protoreflect.ValueOfMessage(msg)); err != nil {
+		return err
+	}
+
+	value, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg.Interface())
+	if err != nil {
+		return err
+	}
+	setAny(anyMsg, typeURL, value)
+	return nil
+}
+
+// decodeRaw читает Any из двоичной формы {type_url, value}. Пустой документ
+// дает пустой Any.
+func (pc *protobufAnyCodec) decodeRaw(doc bson.Raw, anyMsg protoreflect.Message) error {
+	var typeURL string
+	var value []byte
+	if typeURLValue, err := doc.LookupErr(anyRawTypeURLKey); err == nil {
+		str, ok := typeURLValue.StringValueOK()
+		if !ok {
+			return fmt.Errorf("google.protobuf.Any %q must be a string", anyRawTypeURLKey)
+		}
+		typeURL = str
+	}
+	if rawValue, err := doc.LookupErr(anyRawValueKey); err == nil {
+		_, data, ok := rawValue.BinaryOK()
+		if !ok {
+			return fmt.Errorf("google.protobuf.Any %q must be a binary", anyRawValueKey)
+		}
+		value = data
+	}
+	setAny(anyMsg, typeURL, value)
+	return nil
+}
+
+// getAny возвращает поля type_url и value сообщения вида google.protobuf.Any.
+func getAny(msg protoreflect.Message) (string, []byte) {
+	fields := msg.Descriptor().Fields()
+	typeURL := msg.Get(fields.ByName(anyTypeURLFieldName)).String()
+	value := msg.Get(fields.ByName(anyValueFieldName)).Bytes()
+	return typeURL, value
+}
+
+// setAny заполняет поля type_url и value сообщения вида google.protobuf.Any.
+func setAny(msg protoreflect.Message, typeURL string, value []byte) {
+	fields := msg.Descriptor().Fields()
+	msg.Set(fields.ByName(anyTypeURLFieldName), protoreflect.ValueOfString(typeURL))
+	msg.Set(fields.ByName(anyValueFieldName), protoreflect.ValueOfBytes(value))
+}
@@ -0,0 +1,107 @@
+package codec
+
+import (
+	"testing"
+	"time"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestAnyEmbeddedMessage(t *testing.T) {
+	assert := asrt.New(t)
+	nested, err := anypb.New(&gen.NestedMessage{NestedStringField: "nested", NestedInt32Field: 32})
+	assert.Nil(err)
+
+	bsonData, err := Marshal(&gen.Example{AnyField: nested})
+	assert.Nil(err)
+	typeURL, err := bson.Raw(bsonData).LookupErr("any_field", "@type")
+	assert.Nil(err)
+	assert.Equal(nested.TypeUrl, typeURL.StringValue())
+	field, err := bson.Raw(bsonData).LookupErr("any_field", "nested_string_field")
+	assert.Nil(err, "fields of the embedded message must be queryable")
+	assert.Equal("nested", field.StringValue())
+
+	decoded := &gen.Example{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(nested, decoded.AnyField), "got %v", decoded.AnyField)
+}
+
+func TestAnyWellKnownType(t *testing.T) {
+	assert := asrt.New(t)
+	ts, err := anypb.New(timestamppb.New(time.Date(2021, 8, 23, 0, 0, 0, 0, time.UTC)))
+	assert.Nil(err)
+
+	bsonData, err := Marshal(&gen.Example{AnyField: ts})
+	assert.Nil(err)
+	value, err := bson.Raw(bsonData).LookupErr("any_field", "value")
+	assert.Nil(err)
+	assert.Equal(bsontype.DateTime, value.Type)
+
+	decoded := &gen.Example{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(ts, decoded.AnyField), "got %v", decoded.AnyField)
+}
+
+func TestAnyUnknownType(t *testing.T) {
+	assert := asrt.New(t)
+	nested, err := anypb.New(&gen.NestedMessage{NestedStringField: "nested"})
+	assert.Nil(err)
+
+	// В пустом реестре тип не найдется, и Any сохранится в двоичной форме.
+	options := MarshalOptions{Resolver: new(protoregistry.Types)}
+	bsonData, err := options.Marshal(&gen.Example{AnyField: nested})
+	assert.Nil(err)
+	value, err := bson.Raw(bsonData).LookupErr("any_field", "value")
+	assert.Nil(err)
+	assert.Equal(bsontype.Binary, value.Type)
+
+	decoded := &gen.Example{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(nested, decoded.AnyField), "got %v", decoded.AnyField)
+}
+
+func TestAnyDynamic(t *testing.T) {
+	assert := asrt.New(t)
+	nested, err := anypb.New(&gen.NestedMessage{NestedStringField: "nested", NestedInt32Field: 32})
+	assert.Nil(err)
+	msg := &gen.Example{AnyField: nested}
+	expected, err := Marshal(msg)
+	assert.Nil(err)
+
+	dynamic := dynamicpb.NewMessage(msg.ProtoReflect().Descriptor())
+	wireData, err := proto.Marshal(msg)
+	assert.Nil(err)
+	assert.Nil(proto.Unmarshal(wireData, dynamic))
+	bsonData, err := Marshal(dynamic)
+	assert.Nil(err)
+	assert.Equal(expected, bsonData)
+
+	decoded := dynamicpb.NewMessage(msg.ProtoReflect().Descriptor())
+	assert.Nil(Unmarshal(bsonData, decoded))
+	wireData, err = proto.Marshal(decoded)
+	assert.Nil(err)
+	roundTrip := &gen.Example{}
+	assert.Nil(proto.Unmarshal(wireData, roundTrip))
+	assert.True(proto.Equal(msg, roundTrip), "got %v", roundTrip)
+}
+
+func TestAnyUnresolvedType(t *testing.T) {
+	assert := asrt.New(t)
+	nested, err := anypb.New(&gen.NestedMessage{NestedStringField: "nested"})
+	assert.Nil(err)
+	bsonData, err := Marshal(&gen.Example{AnyField: nested})
+	assert.Nil(err)
+
+	// Документ с "@type" нельзя декодировать без описания типа.
+	options := UnmarshalOptions{Resolver: new(protoregistry.Types)}
+	assert.NotNil(options.Unmarshal(bsonData, &gen.Example{}))
+}
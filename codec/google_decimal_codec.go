@@ -0,0 +1,265 @@
+package codec
+
+import (
+	"fmt"
+	"math/big"
+
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	// moneyAmountKey - ключ суммы google.type.Money в документе.
+	moneyAmountKey = "amount"
+
+	currencyCodeFieldName = "currency_code"
+	unitsFieldName        = "units"
+	decimalValueFieldName = "value"
+
+	// moneyNanosExp - десятичная экспонента nanos у google.type.Money.
+	moneyNanosExp = -9
+)
+
+var nanosPerUnit = big.NewInt(1e9)
+
+// protobufMoneyCodec кодирует/декодирует google.type.Money в документ
+// {currency_code, amount}, где amount - Decimal128 с точным значением
+// units + nanos, так что суммы можно складывать в агрегациях. Ключ
+// currency_code задается стратегией именования. Декодирование понимает и
+// прежнее представление {currency_code, units, nanos}. Суммы, в которых больше
+// 9 знаков после запятой, - ошибка.
+//
+// Сообщение читается через protoreflect по именам полей, поэтому кодек не
+// зависит от сгенерированного пакета google.type.
+type protobufMoneyCodec struct {
+	registry *CodecsRegistry
+}
+
+func newProtobufMoneyCodec(r *CodecsRegistry) *protobufMoneyCodec {
+	return &protobufMoneyCodec{
+		registry: r,
+	}
+}
+
+func (pc *protobufMoneyCodec) EncodeValue(
+	_ bsoncodec.EncodeContext, w bsonrw.ValueWriter, val protoreflect.Value,
+) error {
+	msg := val.Message()
+	fields := msg.Descriptor().Fields()
+	currencyField := fields.ByName(currencyCodeFieldName)
+	units := msg.Get(fields.ByName(unitsFieldName)).Int()
+	nanos := msg.Get(fields.ByName(nanosFieldName)).Int()
+	amount, err := moneyToDecimal128(units, int32(nanos))
+	if err != nil {
+		return err
+	}
+
+	dw, err := w.WriteDocument()
+	if err != nil {
+		return err
+	}
+	vw, err := dw.WriteDocumentElement(fieldKey(pc.registry.MarshalOptions.FieldNaming, currencyField))
+	if err != nil {
+		return err
+	}
+	if err = vw.WriteString(msg.Get(currencyField).String()); err != nil {
+		return err
+	}
+	if vw, err = dw.WriteDocumentElement(moneyAmountKey); err != nil {
+		return err
+	}
+	if err = vw.WriteDecimal128(amount); err != nil {
+		return err
+	}
+	return dw.WriteDocumentEnd()
+}
+
+func (pc *protobufMoneyCodec) DecodeValue(
+	_ bsoncodec.DecodeContext, r bsonrw.ValueReader, val protoreflect.Value,
+) error {
+	msg := val.Message()
+	fields := msg.Descriptor().Fields()
+	naming := pc.registry.UnmarshalOptions.FieldNaming
+	currencyField := fields.ByName(currencyCodeFieldName)
+	unitsField := fields.ByName(unitsFieldName)
+	nanosField := fields.ByName(nanosFieldName)
+
+	dr, err := r.ReadDocument()
+	if err != nil {
+		return err
+	}
+	for {
+		key, vr, err := dr.ReadElement()
+		if err == bsonrw.ErrEOD {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		switch key {
+		case fieldKey(naming, currencyField):
+			code, err := vr.ReadString()
+			if err != nil {
+				return err
+			}
+			msg.Set(currencyField, protoreflect.ValueOfString(code))
+		case moneyAmountKey:
+			amount, err := readDecimal(vr)
+			if err != nil {
+				return err
+			}
+			units, nanos, err := decimal128ToMoney(amount)
+			if err != nil {
+				return err
+			}
+			msg.Set(unitsField, protoreflect.ValueOfInt64(units))
+			msg.Set(nanosField, protoreflect.ValueOfInt32(nanos))
+		case fieldKey(naming, unitsField):
+			units, err := readInteger(vr)
+			if err != nil {
+				return err
+			}
+			msg.Set(unitsField, protoreflect.ValueOfInt64(units))
+		case fieldKey(naming, nanosField):
+			nanos, err := decodeNumericKind(vr, nanosField.Kind(), false)
+			if err != nil {
+				return err
+			}
+			msg.Set(nanosField, nanos)
+		default:
+			if err = vr.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// protobufDecimalCodec кодирует/декодирует google.type.Decimal в Decimal128.
+// Значения, которые Decimal128 не может представить точно, - ошибка.
+type protobufDecimalCodec struct {
+	registry *CodecsRegistry
+}
+
+func newProtobufDecimalCodec(r *CodecsRegistry) *protobufDecimalCodec {
+	return &protobufDecimalCodec{
+		registry: r,
+	}
+}
+
+func (pc *protobufDecimalCodec) EncodeValue(
+	_ bsoncodec.EncodeContext, w bsonrw.ValueWriter, val protoreflect.Value,
+) error {
+	msg := val.Message()
+	value := msg.Get(msg.Descriptor().Fields().ByName(decimalValueFieldName)).String()
+	// Незаполненное значение - это ноль.
+	if value == "" {
+		value = "0"
+	}
+	d, err := primitive.ParseDecimal128(value)
+	if err != nil || d.IsNaN() || d.IsInf() != 0 {
+		return fmt.Errorf("decimal %q can't be represented as decimal128 exactly", value)
+	}
+	return w.WriteDecimal128(d)
+}
+
+func (pc *protobufDecimalCodec) DecodeValue(
+	_ bsoncodec.DecodeContext, r bsonrw.ValueReader, val protoreflect.Value,
+) error {
+	var value string
+	if r.Type() == bsontype.String {
+		str, err := r.ReadString()
+		if err != nil {
+			return err
+		}
+		// NaN и бесконечности не являются значениями google.type.Decimal.
+		if d, err := primitive.ParseDecimal128(str); err != nil || d.IsNaN() || d.IsInf() != 0 {
+			return fmt.Errorf("string %q is not a finite decimal", str)
+		}
+		value = str
+	} else {
+		d, err := readDecimal(r)
+		if err != nil {
+			return err
+		}
+		value = d.String()
+	}
+	msg := val.Message()
+	msg.Set(msg.Descriptor().Fields().ByName(decimalValueFieldName), protoreflect.ValueOfString(value))
+	return nil
+}
+
+// readDecimal читает конечное десятичное число из Decimal128, либо из целого.
+func readDecimal(r bsonrw.ValueReader) (primitive.Decimal128, error) {
+	switch r.Type() {
+	case bsontype.Decimal128:
+		d, err := r.ReadDecimal128()
+		if err != nil {
+			return d, err
+		}
+		if d.IsNaN() || d.IsInf() != 0 {
+			return d, fmt.Errorf("decimal128 %s is not a finite number", d)
+		}
+		return d, nil
+	case bsontype.Int32, bsontype.Int64:
+		i, err := readInteger(r)
+		if err != nil {
+			return primitive.Decimal128{}, err
+		}
+		d, _ := primitive.ParseDecimal128FromBigInt(big.NewInt(i), 0)
+		return d, nil
+	}
+	return primitive.Decimal128{}, fmt.Errorf("can't decode decimal from BSON %s", r.Type())
+}
+
+// moneyToDecimal128 возвращает точную сумму units + nanos/1e9. Знаки units и
+// nanos должны совпадать, как требует google.type.Money.
+func moneyToDecimal128(units int64, nanos int32) (primitive.Decimal128, error) {
+	if nanos <= -1e9 || nanos >= 1e9 || units > 0 && nanos < 0 || units < 0 && nanos > 0 {
+		return primitive.Decimal128{}, fmt.Errorf("invalid money: units %d, nanos %d", units, nanos)
+	}
+	coef := new(big.Int).Mul(big.NewInt(units), nanosPerUnit)
+	coef.Add(coef, big.NewInt(int64(nanos)))
+	// Незначащие нули дробной части отбрасываются: 1.5, а не 1.500000000.
+	exp := moneyNanosExp
+	ten := big.NewInt(10)
+	for exp < 0 && coef.Sign() != 0 {
+		quo, mod := new(big.Int).QuoRem(coef, ten, new(big.Int))
+		if mod.Sign() != 0 {
+			break
+		}
+		coef = quo
+		exp++
+	}
+	if coef.Sign() == 0 {
+		exp = 0
+	}
+	d, _ := primitive.ParseDecimal128FromBigInt(coef, exp)
+	return d, nil
+}
+
+// decimal128ToMoney разбивает d на units и nanos. Больше 9 знаков после
+// запятой, либо целая часть вне int64, - ошибка.
+func decimal128ToMoney(d primitive.Decimal128) (int64, int32, error) {
+	coef, exp, err := d.BigInt()
+	if err != nil {
+		return 0, 0, fmt.Errorf("decimal128 %s is not a money amount: %w", d, err)
+	}
+	ten := big.NewInt(10)
+	for ; exp > moneyNanosExp && coef.Sign() != 0 && coef.BitLen() <= 128; exp-- {
+		coef.Mul(coef, ten)
+	}
+	for ; exp < moneyNanosExp && coef.Sign() != 0; exp++ {
+		var mod big.Int
+		coef.QuoRem(coef, ten, &mod)
+		if mod.Sign() != 0 {
+			return 0, 0, fmt.Errorf("decimal128 %s has more than 9 fractional digits", d)
+		}
+	}
+	units, nanos := new(big.Int).QuoRem(coef, nanosPerUnit, new(big.Int))
+	if !units.IsInt64() || coef.Sign() != 0 && exp > moneyNanosExp {
+		return 0, 0, fmt.Errorf("decimal128 %s overflows money units", d)
+	}
+	return units.Int64(), int32(nanos.Int64()), nil
+}
@@ -0,0 +1,173 @@
+package codec
+
+import (
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// googleTypeFile описывает сообщения google.type, которые нужны тестам, без
+// зависимости от сгенерированного пакета, и сообщения Holder и Spot с полями
+// этих типов.
+func googleTypeFile(t *testing.T) pref.FileDescriptor {
+	t.Helper()
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     kind.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	const (
+		stringType  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		int64Type   = descriptorpb.FieldDescriptorProto_TYPE_INT64
+		int32Type   = descriptorpb.FieldDescriptorProto_TYPE_INT32
+		doubleType  = descriptorpb.FieldDescriptorProto_TYPE_DOUBLE
+		messageType = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("google/type/test.proto"),
+		Package: proto.String("google.type"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Money"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("currency_code", 1, stringType, ""),
+				field("units", 2, int64Type, ""),
+				field("nanos", 3, int32Type, ""),
+			}},
+			{Name: proto.String("Decimal"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("value", 1, stringType, ""),
+			}},
+			{Name: proto.String("LatLng"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("latitude", 1, doubleType, ""),
+				field("longitude", 2, doubleType, ""),
+			}},
+			{Name: proto.String("Holder"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("price", 1, messageType, ".google.type.Money"),
+				field("rate", 2, messageType, ".google.type.Decimal"),
+			}},
+			{Name: proto.String("Spot"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("location", 1, messageType, ".google.type.LatLng"),
+			}},
+		},
+	}, new(protoregistry.Files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fd
+}
+
+// newMoney возвращает google.type.Money из файла fd.
+func newMoney(fd pref.FileDescriptor, code string, units int64, nanos int32) *dynamicpb.Message {
+	money := dynamicpb.NewMessage(fd.Messages().ByName("Money"))
+	fields := money.Descriptor().Fields()
+	money.Set(fields.ByName("currency_code"), pref.ValueOfString(code))
+	money.Set(fields.ByName("units"), pref.ValueOfInt64(units))
+	money.Set(fields.ByName("nanos"), pref.ValueOfInt32(nanos))
+	return money
+}
+
+func TestMoney(t *testing.T) {
+	assert := asrt.New(t)
+	fd := googleTypeFile(t)
+	holderDesc := fd.Messages().ByName("Holder")
+	decimalDesc := fd.Messages().ByName("Decimal")
+
+	for _, amount := range []struct {
+		units    int64
+		nanos    int32
+		expected string
+	}{
+		{12, 500000000, "12.5"},
+		{-3, -10, "-3.00000001"},
+		{0, 0, "0"},
+		{9223372036854775807, 999999999, "9223372036854775807.999999999"},
+	} {
+		holder := dynamicpb.NewMessage(holderDesc)
+		holder.Set(holderDesc.Fields().ByName("price"), pref.ValueOfMessage(newMoney(fd, "USD", amount.units, amount.nanos)))
+		rate := dynamicpb.NewMessage(decimalDesc)
+		rate.Set(decimalDesc.Fields().ByName("value"), pref.ValueOfString("0.25"))
+		holder.Set(holderDesc.Fields().ByName("rate"), pref.ValueOfMessage(rate))
+
+		bsonData, err := Marshal(holder)
+		assert.Nil(err)
+		doc := bson.Raw(bsonData)
+		assert.Equal("USD", doc.Lookup("price", "currency_code").StringValue())
+		price := doc.Lookup("price", "amount")
+		assert.Equal(bsontype.Decimal128, price.Type)
+		assert.Equal(amount.expected, price.Decimal128().String())
+		assert.Equal(bsontype.Decimal128, doc.Lookup("rate").Type)
+
+		decoded := dynamicpb.NewMessage(holderDesc)
+		assert.Nil(Unmarshal(bsonData, decoded))
+		assert.True(proto.Equal(holder, decoded), amount.expected)
+	}
+
+	_, err := Marshal(newMoney(fd, "USD", 1, -1))
+	assert.NotNil(err, "units and nanos must have the same sign")
+}
+
+func TestDecimal128ToMoney(t *testing.T) {
+	assert := asrt.New(t)
+	parse := func(s string) primitive.Decimal128 {
+		d, err := primitive.ParseDecimal128(s)
+		assert.Nil(err)
+		return d
+	}
+
+	units, nanos, err := decimal128ToMoney(parse("1.5E+3"))
+	assert.Nil(err)
+	assert.Equal(int64(1500), units)
+	assert.Equal(int32(0), nanos)
+
+	units, nanos, err = decimal128ToMoney(parse("-0.000000001000"))
+	assert.Nil(err)
+	assert.Equal(int64(0), units)
+	assert.Equal(int32(-1), nanos)
+
+	for _, value := range []string{"0.0000000001", "1E+19", "NaN"} {
+		_, _, err = decimal128ToMoney(parse(value))
+		assert.NotNil(err, value)
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	assert := asrt.New(t)
+	fd := googleTypeFile(t)
+	holderDesc := fd.Messages().ByName("Holder")
+	decimalDesc := fd.Messages().ByName("Decimal")
+	newHolder := func(value string) *dynamicpb.Message {
+		holder := dynamicpb.NewMessage(holderDesc)
+		rate := dynamicpb.NewMessage(decimalDesc)
+		rate.Set(decimalDesc.Fields().ByName("value"), pref.ValueOfString(value))
+		holder.Set(holderDesc.Fields().ByName("rate"), pref.ValueOfMessage(rate))
+		return holder
+	}
+
+	// Decimal128 хранит не больше 34 значащих цифр.
+	_, err := Marshal(newHolder("1.234567890123456789012345678901234"))
+	assert.Nil(err)
+	_, err = Marshal(newHolder("1.2345678901234567890123456789012345"))
+	assert.NotNil(err, "35 significant digits can't be stored exactly")
+
+	for _, value := range []string{"NaN", "Infinity", "-Inf"} {
+		bsonData, err := bson.Marshal(bson.D{{Key: "rate", Value: value}})
+		assert.Nil(err)
+		assert.NotNil(Unmarshal(bsonData, dynamicpb.NewMessage(holderDesc)), value)
+	}
+}
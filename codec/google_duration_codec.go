@@ -0,0 +1,209 @@
+package codec
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// DurationMode задает BSON представление google.protobuf.Duration.
+type DurationMode int
+
+const (
+	// DurationNanos - int64 наносекунды. Длительности больше ~292 лет в него не
+	// помещаются. Используется по умолчанию.
+	DurationNanos DurationMode = iota
+	// DurationMillis - int64 миллисекунды. Точность ниже миллисекунды теряется.
+	DurationMillis
+	// DurationSeconds - double секунды. Точность ограничена мантиссой double.
+	DurationSeconds
+	// DurationString - строка в формате protojson, например "1.500s".
+	DurationString
+)
+
+// maxDurationSeconds - предел google.protobuf.Duration, примерно 10000 лет.
+const maxDurationSeconds = 315576000000
+
+// protobufDurationCodec кодирует/декодирует google.protobuf.Duration в
+// представление, заданное MarshalOptions.DurationMode. Декодирование понимает
+// любое из представлений, а также поддокумент {seconds, nanos}. Целые числа
+// трактуются в единицах UnmarshalOptions.DurationMode: миллисекундах для
+// DurationMillis и наносекундах в остальных случаях.
+type protobufDurationCodec struct {
+	registry *CodecsRegistry
+}
+
+func newProtobufDurationCodec(r *CodecsRegistry) *protobufDurationCodec {
+	return &protobufDurationCodec{
+		registry: r,
+	}
+}
+
+func (pc *protobufDurationCodec) EncodeValue(
+	_ bsoncodec.EncodeContext, w bsonrw.ValueWriter, val protoreflect.Value,
+) error {
+	seconds, nanos := getSecondsNanos(val.Message())
+	if err := validateDuration(seconds, nanos); err != nil {
+		return err
+	}
+
+	switch pc.registry.MarshalOptions.DurationMode {
+	case DurationNanos:
+		if seconds > math.MaxInt64/int64(time.Second) || seconds < math.MinInt64/int64(time.Second) {
+			return fmt.Errorf("duration of %d seconds overflows int64 nanoseconds", seconds)
+		}
+		return w.WriteInt64(seconds*int64(time.Second) + int64(nanos))
+	case DurationMillis:
+		return w.WriteInt64(seconds*1000 + int64(nanos)/int64(time.Millisecond))
+	case DurationSeconds:
+		return w.WriteDouble(float64(seconds) + float64(nanos)/float64(time.Second))
+	case DurationString:
+		return w.WriteString(formatDuration(seconds, nanos))
+	}
+	return fmt.Errorf("unknown duration mode %d", pc.registry.MarshalOptions.DurationMode)
+}
+
+func (pc *protobufDurationCodec) DecodeValue(
+	_ bsoncodec.DecodeContext, r bsonrw.ValueReader, val protoreflect.Value,
+) error {
+	var seconds int64
+	var nanos int32
+	var err error
+
+	switch r.Type() {
+	case bsontype.Int32, bsontype.Int64:
+		n, err := readInteger(r)
+		if err != nil {
+			return err
+		}
+		unit := int64(time.Nanosecond)
+		if pc.registry.UnmarshalOptions.DurationMode == DurationMillis {
+			unit = int64(time.Millisecond)
+		}
+		perSecond := int64(time.Second) / unit
+		seconds, nanos = n/perSecond, int32(n%perSecond*unit)
+	case bsontype.Double:
+		f, err := r.ReadDouble()
+		if err != nil {
+			return err
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) || math.Abs(f) > maxDurationSeconds {
+			return fmt.Errorf("duration %v seconds out of range", f)
+		}
+		whole, frac := math.Modf(f)
+		seconds, nanos = int64(whole), int32(math.Round(frac*float64(time.Second)))
+		// Округление дробной части может дать целую секунду.
+		if nanos >= int32(time.Second) || nanos <= -int32(time.Second) {
+			seconds, nanos = seconds+int64(nanos/int32(time.Second)), nanos%int32(time.Second)
+		}
+	case bsontype.String:
+		str, err := r.ReadString()
+		if err != nil {
+			return err
+		}
+		if seconds, nanos, err = parseDuration(str); err != nil {
+			return err
+		}
+	case bsontype.EmbeddedDocument:
+		if seconds, nanos, err = readSecondsNanos(r); err != nil {
+			return err
+		}
+	case bsontype.Null:
+		return r.ReadNull()
+	default:
+		return fmt.Errorf(
+			"can't decode %s from BSON %s", val.Message().Descriptor().FullName(), r.Type(),
+		)
+	}
+	if err = validateDuration(seconds, nanos); err != nil {
+		return err
+	}
+	setSecondsNanos(val.Message(), seconds, nanos)
+
+	return nil
+}
+
+// validateDuration проверяет ограничения google.protobuf.Duration: диапазон
+// секунд и совпадение знаков секунд и наносекунд.
+func validateDuration(seconds int64, nanos int32) error {
+	switch {
+	case seconds < -maxDurationSeconds || seconds > maxDurationSeconds:
+		return fmt.Errorf("duration seconds %d out of range", seconds)
+	case nanos <= -int32(time.Second) || nanos >= int32(time.Second):
+		return fmt.Errorf("duration nanos %d out of range", nanos)
+	case seconds > 0 && nanos < 0, seconds < 0 && nanos > 0:
+		return fmt.Errorf("duration seconds %d and nanos %d have different signs", seconds, nanos)
+	}
+	return nil
+}
+
+// formatDuration форматирует длительность так же, как protojson: секунды с
+// дробной частью из 0, 3, 6 или 9 знаков и суффиксом "s".
+func formatDuration(seconds int64, nanos int32) string {
+	sign := ""
+	if seconds < 0 || nanos < 0 {
+		sign, seconds, nanos = "-", -seconds, -nanos
+	}
+	str := sign + strconv.FormatInt(seconds, 10)
+	if nanos != 0 {
+		frac := fmt.Sprintf("%09d", nanos)
+		for strings.HasSuffix(frac, "000") {
+			frac = frac[:len(frac)-3]
+		}
+		str += "." + frac
+	}
+	return str + "s"
+}
+
+// parseDuration разбирает длительность в формате protojson ("-1.5s"), либо в
+// формате time.ParseDuration ("1m30s").
+func parseDuration(str string) (int64, int32, error) {
+	if seconds, nanos, ok := parseSecondsDuration(str); ok {
+		return seconds, nanos, nil
+	}
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid duration %q", str)
+	}
+	return int64(d / time.Second), int32(d % time.Second), nil
+}
+
+// parseSecondsDuration разбирает длительность в формате protojson без потери
+// точности и без ограничения time.Duration в ~292 года.
+func parseSecondsDuration(str string) (int64, int32, bool) {
+	if !strings.HasSuffix(str, "s") {
+		return 0, 0, false
+	}
+	str = strings.TrimSuffix(str, "s")
+	negative := strings.HasPrefix(str, "-")
+	str = strings.TrimPrefix(str, "-")
+
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+	}
+	if intPart == "" || len(fracPart) > 9 || strings.ContainsAny(intPart+fracPart, "+-") {
+		return 0, 0, false
+	}
+	seconds, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	var nanos int64
+	if fracPart != "" {
+		if nanos, err = strconv.ParseInt(fracPart+strings.Repeat("0", 9-len(fracPart)), 10, 32); err != nil {
+			return 0, 0, false
+		}
+	}
+	if negative {
+		seconds, nanos = -seconds, -nanos
+	}
+	return seconds, int32(nanos), true
+}
@@ -0,0 +1,73 @@
+package codec
+
+import (
+	"testing"
+	"time"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestDurationModes(t *testing.T) {
+	d := durationpb.New(-(90*time.Minute + 1500*time.Microsecond))
+	cases := []struct {
+		mode  DurationMode
+		value interface{}
+	}{
+		{mode: DurationNanos, value: int64(-5400001500000)},
+		{mode: DurationMillis, value: int64(-5400001)},
+		{mode: DurationSeconds, value: -5400.0015},
+		{mode: DurationString, value: "-5400.001500s"},
+	}
+	for _, c := range cases {
+		assert := asrt.New(t)
+
+		bsonData, err := MarshalOptions{DurationMode: c.mode}.Marshal(&gen.WellKnownTypes{Duration: d})
+		assert.Nil(err)
+		value, err := bson.Raw(bsonData).LookupErr("duration")
+		assert.Nil(err)
+		switch expected := c.value.(type) {
+		case int64:
+			assert.Equal(expected, value.Int64())
+		case float64:
+			assert.Equal(expected, value.Double())
+		case string:
+			assert.Equal(expected, value.StringValue())
+		}
+
+		decoded := &gen.WellKnownTypes{}
+		err = UnmarshalOptions{DurationMode: c.mode}.Unmarshal(bsonData, decoded)
+		assert.Nil(err)
+		expected := d
+		if c.mode == DurationMillis {
+			expected = durationpb.New(d.AsDuration().Truncate(time.Millisecond))
+		}
+		assert.True(proto.Equal(expected, decoded.Duration), "mode %d: got %v", c.mode, decoded.Duration)
+	}
+}
+
+func TestDurationDecoding(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		expected *durationpb.Duration
+	}{
+		{value: "1m30s", expected: durationpb.New(90 * time.Second)},
+		{value: "315576000000s", expected: &durationpb.Duration{Seconds: 315576000000}},
+		{value: bson.D{{Key: "seconds", Value: int64(3)}, {Key: "nanos", Value: int32(5)}},
+			expected: &durationpb.Duration{Seconds: 3, Nanos: 5}},
+		{value: int32(1500), expected: &durationpb.Duration{Nanos: 1500}},
+	}
+	for _, c := range cases {
+		assert := asrt.New(t)
+
+		bsonData, err := bson.Marshal(bson.D{{Key: "duration", Value: c.value}})
+		assert.Nil(err)
+		decoded := &gen.WellKnownTypes{}
+		assert.Nil(Unmarshal(bsonData, decoded))
+		assert.True(proto.Equal(c.expected, decoded.Duration), "%v: got %v", c.value, decoded.Duration)
+	}
+}
@@ -0,0 +1,44 @@
+package codec
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// protobufEmptyCodec кодирует заполненное google.protobuf.Empty как BSON null:
+// ключ остается в документе и сохраняет факт присутствия, а значение не занимает
+// места. Незаполненное поле не пишется вовсе (см. protobufMessageCodec).
+// Декодирование понимает как null, так и пустой документ - прежнее
+// представление.
+type protobufEmptyCodec struct {
+	registry *CodecsRegistry
+}
+
+func newProtobufEmptyCodec(r *CodecsRegistry) *protobufEmptyCodec {
+	return &protobufEmptyCodec{
+		registry: r,
+	}
+}
+
+func (pc *protobufEmptyCodec) EncodeValue(
+	_ bsoncodec.EncodeContext, w bsonrw.ValueWriter, _ protoreflect.Value,
+) error {
+	return w.WriteNull()
+}
+
+func (pc *protobufEmptyCodec) DecodeValue(
+	_ bsoncodec.DecodeContext, r bsonrw.ValueReader, _ protoreflect.Value,
+) error {
+	switch r.Type() {
+	case bsontype.Null:
+		return r.ReadNull()
+	case bsontype.EmbeddedDocument:
+		// Поля неизвестные Empty, если они есть, пропускаются.
+		return r.Skip()
+	}
+	return fmt.Errorf("can't decode google.protobuf.Empty from BSON %s", r.Type())
+}
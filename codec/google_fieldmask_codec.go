@@ -0,0 +1,199 @@
+package codec
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// FieldMaskMode задает BSON представление google.protobuf.FieldMask.
+type FieldMaskMode int
+
+const (
+	// FieldMaskArray - массив строк путей. Используется по умолчанию.
+	FieldMaskArray FieldMaskMode = iota
+	// FieldMaskString - пути, объединенные через запятую, как в protojson.
+	FieldMaskString
+)
+
+// fieldMaskPathsSeparator разделяет пути в строковом представлении FieldMask.
+const fieldMaskPathsSeparator = ","
+
+// pathNaming реализуют стратегии именования, которые умеют переводить пути
+// FieldMask без дескрипторов сообщений. Пути в FieldMask не знают, к какому
+// сообщению относятся, поэтому стратегии без этого интерфейса (FieldNumbers,
+// NamingFunc) оставляют пути как есть.
+type pathNaming interface {
+	encodePathSegment(segment string) string
+	decodePathSegment(segment string) string
+}
+
+func (protoNames) encodePathSegment(segment string) string { return segment }
+
+func (protoNames) decodePathSegment(segment string) string { return segment }
+
+// encodePathSegment переводит имя поля в lowerCamelCase так же, как protoc
+// получает JSON имя поля.
+func (jsonNames) encodePathSegment(segment string) string {
+	var b strings.Builder
+	upperNext := false
+	for _, c := range segment {
+		switch {
+		case c == '_':
+			upperNext = true
+		case upperNext && 'a' <= c && c <= 'z':
+			b.WriteRune(c - 'a' + 'A')
+			upperNext = false
+		default:
+			b.WriteRune(c)
+			upperNext = false
+		}
+	}
+	return b.String()
+}
+
+// decodePathSegment переводит lowerCamelCase имя обратно в snake_case.
+func (jsonNames) decodePathSegment(segment string) string {
+	var b strings.Builder
+	for _, c := range segment {
+		if 'A' <= c && c <= 'Z' {
+			b.WriteByte('_')
+			c += 'a' - 'A'
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func (n idFieldNaming) encodePathSegment(segment string) string {
+	if pn, ok := n.naming.(pathNaming); ok {
+		return pn.encodePathSegment(segment)
+	}
+	return segment
+}
+
+func (n idFieldNaming) decodePathSegment(segment string) string {
+	if pn, ok := n.naming.(pathNaming); ok {
+		return pn.decodePathSegment(segment)
+	}
+	return segment
+}
+
+// convertPath применяет convert к каждому сегменту пути вида "a.b.c".
+func convertPath(path string, convert func(string) string) string {
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		segments[i] = convert(segment)
+	}
+	return strings.Join(segments, ".")
+}
+
+// protobufFieldMaskCodec кодирует/декодирует google.protobuf.FieldMask в массив
+// путей, либо в одну строку путей через запятую. Пути переводятся активной
+// стратегией именования. Декодирование понимает оба представления.
+type protobufFieldMaskCodec struct {
+	registry *CodecsRegistry
+}
+
+func newProtobufFieldMaskCodec(r *CodecsRegistry) *protobufFieldMaskCodec {
+	return &protobufFieldMaskCodec{
+		registry: r,
+	}
+}
+
+func (pc *protobufFieldMaskCodec) EncodeValue(
+	_ bsoncodec.EncodeContext, w bsonrw.ValueWriter, val protoreflect.Value,
+) error {
+	mask, ok := val.Message().Interface().(*fieldmaskpb.FieldMask)
+	if !ok {
+		return fmt.Errorf("message %s is not fieldmaskpb.FieldMask", val.Message().Descriptor().FullName())
+	}
+	paths := make([]string, len(mask.GetPaths()))
+	for i, path := range mask.GetPaths() {
+		paths[i] = path
+		if pn, ok := namingOrDefault(pc.registry.MarshalOptions.FieldNaming).(pathNaming); ok {
+			paths[i] = convertPath(path, pn.encodePathSegment)
+		}
+	}
+
+	switch pc.registry.MarshalOptions.FieldMaskMode {
+	case FieldMaskArray:
+		aw, err := w.WriteArray()
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			vw, err := aw.WriteArrayElement()
+			if err != nil {
+				return err
+			}
+			if err = vw.WriteString(path); err != nil {
+				return err
+			}
+		}
+		return aw.WriteArrayEnd()
+	case FieldMaskString:
+		for _, path := range paths {
+			if strings.Contains(path, fieldMaskPathsSeparator) {
+				return fmt.Errorf("field mask path %q can't be joined: it contains %q", path, fieldMaskPathsSeparator)
+			}
+		}
+		return w.WriteString(strings.Join(paths, fieldMaskPathsSeparator))
+	}
+	return fmt.Errorf("unknown field mask mode %d", pc.registry.MarshalOptions.FieldMaskMode)
+}
+
+func (pc *protobufFieldMaskCodec) DecodeValue(
+	_ bsoncodec.DecodeContext, r bsonrw.ValueReader, val protoreflect.Value,
+) error {
+	mask, ok := val.Message().Interface().(*fieldmaskpb.FieldMask)
+	if !ok {
+		return fmt.Errorf("message %s is not fieldmaskpb.FieldMask", val.Message().Descriptor().FullName())
+	}
+
+	var paths []string
+	switch r.Type() {
+	case bsontype.Array:
+		ar, err := r.ReadArray()
+		if err != nil {
+			return err
+		}
+		for {
+			vr, err := ar.ReadValue()
+			if err == bsonrw.ErrEOA {
+				break
+			} else if err != nil {
+				return err
+			}
+			path, err := vr.ReadString()
+			if err != nil {
+				return err
+			}
+			paths = append(paths, path)
+		}
+	case bsontype.String:
+		str, err := r.ReadString()
+		if err != nil {
+			return err
+		}
+		if str != "" {
+			paths = strings.Split(str, fieldMaskPathsSeparator)
+		}
+	default:
+		return fmt.Errorf("can't decode google.protobuf.FieldMask from BSON %s", r.Type())
+	}
+
+	pn, convert := namingOrDefault(pc.registry.UnmarshalOptions.FieldNaming).(pathNaming)
+	for i, path := range paths {
+		if convert {
+			paths[i] = convertPath(path, pn.decodePathSegment)
+		}
+	}
+	mask.Paths = paths
+	return nil
+}
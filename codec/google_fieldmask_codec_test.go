@@ -0,0 +1,65 @@
+package codec
+
+import (
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestFieldMask(t *testing.T) {
+	assert := asrt.New(t)
+	msg := &gen.WellKnownTypes{
+		FieldMask: &fieldmaskpb.FieldMask{Paths: []string{"display_name", "audit.created_by"}},
+	}
+
+	bsonData, err := Marshal(msg)
+	assert.Nil(err)
+	value, err := bson.Raw(bsonData).LookupErr("field_mask")
+	assert.Nil(err)
+	assert.Equal(bsontype.Array, value.Type)
+	decoded := &gen.WellKnownTypes{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.Equal(msg.FieldMask.Paths, decoded.FieldMask.Paths)
+
+	bsonData, err = MarshalOptions{
+		FieldMaskMode: FieldMaskString,
+		FieldNaming:   JSONNames,
+	}.Marshal(msg)
+	assert.Nil(err)
+	value, err = bson.Raw(bsonData).LookupErr("fieldMask")
+	assert.Nil(err)
+	assert.Equal("displayName,audit.createdBy", value.StringValue())
+	decoded = &gen.WellKnownTypes{}
+	assert.Nil(UnmarshalOptions{FieldNaming: JSONNames}.Unmarshal(bsonData, decoded))
+	assert.Equal(msg.FieldMask.Paths, decoded.FieldMask.Paths)
+}
+
+func TestEmpty(t *testing.T) {
+	assert := asrt.New(t)
+
+	bsonData, err := Marshal(&gen.WellKnownTypes{Empty: &emptypb.Empty{}})
+	assert.Nil(err)
+	value, err := bson.Raw(bsonData).LookupErr("empty")
+	assert.Nil(err)
+	assert.Equal(bsontype.Null, value.Type)
+	decoded := &gen.WellKnownTypes{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.NotNil(decoded.Empty, "null must keep Empty set")
+
+	bsonData, err = MarshalOptions{EmitNullForUnset: true}.Marshal(&gen.WellKnownTypes{})
+	assert.Nil(err)
+	_, err = bson.Raw(bsonData).LookupErr("empty")
+	assert.NotNil(err, "unset Empty must be omitted")
+
+	bsonData, err = bson.Marshal(bson.D{{Key: "empty", Value: bson.D{}}})
+	assert.Nil(err)
+	decoded = &gen.WellKnownTypes{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.NotNil(decoded.Empty, "empty document must be accepted")
+}
@@ -0,0 +1,363 @@
+package codec
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// NonJSONPolicy определяет, во что превращаются BSON типы, которых нет в JSON,
+// при декодировании в google.protobuf.Value. Так же обрабатываются BSON int64,
+// которые по модулю больше 2^53 и не помещаются в double без потери точности.
+type NonJSONPolicy int
+
+const (
+	// NonJSONToString - типы со строковым представлением становятся строками:
+	// ObjectID - hex, DateTime - RFC 3339, Decimal128 - десятичная запись,
+	// Binary - base64, Symbol и JavaScript - их текст, большие int64 -
+	// десятичная запись. Остальные (Timestamp, Regex, MinKey, ...) становятся
+	// объектами Extended JSON. Используется по умолчанию.
+	NonJSONToString NonJSONPolicy = iota
+	// NonJSONToExtendedJSON - все такие типы становятся объектами relaxed
+	// Extended JSON, например {"$oid": "..."} или {"$date": "..."}. Большие
+	// int64 - объектами {"$numberLong": "..."}.
+	NonJSONToExtendedJSON
+	// NonJSONError - такие типы приводят к ошибке декодирования.
+	NonJSONError
+)
+
+// protobufStructCodec кодирует/декодирует google.protobuf.Struct, Value и
+// ListValue в родные BSON значения: Struct - во вложенный документ, ListValue -
+// в массив, Value - в соответствующий скаляр, null, документ или массив.
+// Сообщения обрабатываются через protoreflect, поэтому подходят и
+// динамические (dynamicpb) сообщения.
+type protobufStructCodec struct {
+	registry *CodecsRegistry
+}
+
+func newProtobufStructCodec(r *CodecsRegistry) *protobufStructCodec {
+	return &protobufStructCodec{
+		registry: r,
+	}
+}
+
+const (
+	structFieldsFieldName = "fields"
+	listValuesFieldName   = "values"
+	valueKindOneofName    = "kind"
+	nullValueFieldName    = "null_value"
+	numberValueFieldName  = "number_value"
+	stringValueFieldName  = "string_value"
+	boolValueFieldName    = "bool_value"
+	structValueFieldName  = "struct_value"
+	listValueFieldName    = "list_value"
+)
+
+// maxJSONSafeInteger - наибольшее по модулю целое, которое double (number в
+// google.protobuf.Value) хранит без потери точности.
+const maxJSONSafeInteger = 1 << 53
+
+func (pc *protobufStructCodec) EncodeValue(
+	_ bsoncodec.EncodeContext, w bsonrw.ValueWriter, val protoreflect.Value,
+) error {
+	msg := val.Message()
+	switch msg.Descriptor().FullName() {
+	case ProtobufKindStruct:
+		return pc.encodeStruct(w, msg)
+	case ProtobufKindListValue:
+		return pc.encodeList(w, msg)
+	case ProtobufKindValue:
+		return pc.encodeValue(w, msg)
+	}
+	return fmt.Errorf(
+		"message %s is not google.protobuf.Struct, Value or ListValue", msg.Descriptor().FullName(),
+	)
+}
+
+func (pc *protobufStructCodec) encodeStruct(w bsonrw.ValueWriter, msg protoreflect.Message) error {
+	fields := msg.Get(msg.Descriptor().Fields().ByName(structFieldsFieldName)).Map()
+	dw, err := w.WriteDocument()
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, fields.Len())
+	fields.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, key.String())
+		return true
+	})
+	if pc.registry.MarshalOptions.FieldOrder != AnyOrder {
+		sort.Strings(keys)
+	}
+	for _, key := range keys {
+		vw, err := dw.WriteDocumentElement(key)
+		if err != nil {
+			return err
+		}
+		value := fields.Get(protoreflect.ValueOfString(key).MapKey()).Message()
+		if err = pc.encodeValue(vw, value); err != nil {
+			return wrapEncodeError(key, nil, err)
+		}
+	}
+	return dw.WriteDocumentEnd()
+}
+
+func (pc *protobufStructCodec) encodeList(w bsonrw.ValueWriter, msg protoreflect.Message) error {
+	values := msg.Get(msg.Descriptor().Fields().ByName(listValuesFieldName)).List()
+	aw, err := w.WriteArray()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < values.Len(); i++ {
+		vw, err := aw.WriteArrayElement()
+		if err != nil {
+			return err
+		}
+		if err = pc.encodeValue(vw, values.Get(i).Message()); err != nil {
+			return wrapEncodeError(strconv.Itoa(i), nil, err)
+		}
+	}
+	return aw.WriteArrayEnd()
+}
+
+func (pc *protobufStructCodec) encodeValue(w bsonrw.ValueWriter, msg protoreflect.Message) error {
+	field := msg.WhichOneof(msg.Descriptor().Oneofs().ByName(valueKindOneofName))
+	if field == nil {
+		// Value без выбранного вида, например, незаполненное поле, тоже
+		// превращается в null.
+		return w.WriteNull()
+	}
+	value := msg.Get(field)
+	switch field.Name() {
+	case nullValueFieldName:
+		return w.WriteNull()
+	case numberValueFieldName:
+		return w.WriteDouble(value.Float())
+	case stringValueFieldName:
+		return w.WriteString(value.String())
+	case boolValueFieldName:
+		return w.WriteBoolean(value.Bool())
+	case structValueFieldName:
+		return pc.encodeStruct(w, value.Message())
+	case listValueFieldName:
+		return pc.encodeList(w, value.Message())
+	}
+	return fmt.Errorf("unknown google.protobuf.Value kind %s", field.Name())
+}
+
+func (pc *protobufStructCodec) DecodeValue(
+	_ bsoncodec.DecodeContext, r bsonrw.ValueReader, val protoreflect.Value,
+) error {
+	msg := val.Message()
+	switch msg.Descriptor().FullName() {
+	case ProtobufKindStruct:
+		return pc.decodeStruct(r, msg)
+	case ProtobufKindListValue:
+		return pc.decodeList(r, msg)
+	case ProtobufKindValue:
+		return pc.decodeValue(r, msg)
+	}
+	return fmt.Errorf(
+		"message %s is not google.protobuf.Struct, Value or ListValue", msg.Descriptor().FullName(),
+	)
+}
+
+func (pc *protobufStructCodec) decodeStruct(r bsonrw.ValueReader, msg protoreflect.Message) error {
+	dr, err := r.ReadDocument()
+	if err != nil {
+		return err
+	}
+	field := msg.Descriptor().Fields().ByName(structFieldsFieldName)
+	msg.Clear(field)
+	fields := msg.Mutable(field).Map()
+	strict := pc.registry.UnmarshalOptions.Strict
+	for {
+		key, vr, err := dr.ReadElement()
+		if err == bsonrw.ErrEOD {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		bsonType := vr.Type()
+		mapKey := protoreflect.ValueOfString(key).MapKey()
+		if strict && !utf8.ValidString(key) {
+			return &DecodeError{Path: key, BSONType: bsonType, Err: fmt.Errorf("key is not valid UTF-8")}
+		}
+		if strict && fields.Has(mapKey) {
+			return &DecodeError{Path: key, BSONType: bsonType, Err: fmt.Errorf("duplicate key")}
+		}
+		value := fields.NewValue()
+		if err = pc.decodeValue(vr, value.Message()); err != nil {
+			return wrapDecodeError(key, nil, bsonType, err)
+		}
+		fields.Set(mapKey, value)
+	}
+}
+
+func (pc *protobufStructCodec) decodeList(r bsonrw.ValueReader, msg protoreflect.Message) error {
+	ar, err := r.ReadArray()
+	if err != nil {
+		return err
+	}
+	field := msg.Descriptor().Fields().ByName(listValuesFieldName)
+	msg.Clear(field)
+	values := msg.Mutable(field).List()
+	for i := 0; ; i++ {
+		vr, err := ar.ReadValue()
+		if err == bsonrw.ErrEOA {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		bsonType := vr.Type()
+		value := values.NewElement()
+		if err = pc.decodeValue(vr, value.Message()); err != nil {
+			return wrapDecodeError(strconv.Itoa(i), nil, bsonType, err)
+		}
+		values.Append(value)
+	}
+}
+
+// decodeValue читает BSON значение любого типа в google.protobuf.Value msg.
+// Типы, которых нет в JSON, и int64, которые не помещаются в double без потери
+// точности, обрабатываются согласно UnmarshalOptions.NonJSONTypes.
+func (pc *protobufStructCodec) decodeValue(r bsonrw.ValueReader, msg protoreflect.Message) error {
+	fields := msg.Descriptor().Fields()
+	switch r.Type() {
+	case bsontype.Null:
+		msg.Set(fields.ByName(nullValueFieldName), protoreflect.ValueOfEnum(0))
+		return r.ReadNull()
+	case bsontype.Undefined:
+		msg.Set(fields.ByName(nullValueFieldName), protoreflect.ValueOfEnum(0))
+		return r.ReadUndefined()
+	case bsontype.Double:
+		f, err := r.ReadDouble()
+		msg.Set(fields.ByName(numberValueFieldName), protoreflect.ValueOfFloat64(f))
+		return err
+	case bsontype.Int32, bsontype.Int64:
+		i, err := readInteger(r)
+		if err != nil {
+			return err
+		}
+		if -maxJSONSafeInteger <= i && i <= maxJSONSafeInteger {
+			msg.Set(fields.ByName(numberValueFieldName), protoreflect.ValueOfFloat64(float64(i)))
+			return nil
+		}
+		return pc.setLargeInteger(msg, i)
+	case bsontype.String:
+		str, err := r.ReadString()
+		if err != nil {
+			return err
+		}
+		if pc.registry.UnmarshalOptions.Strict && !utf8.ValidString(str) {
+			return fmt.Errorf("string is not valid UTF-8")
+		}
+		msg.Set(fields.ByName(stringValueFieldName), protoreflect.ValueOfString(str))
+		return nil
+	case bsontype.Boolean:
+		b, err := r.ReadBoolean()
+		msg.Set(fields.ByName(boolValueFieldName), protoreflect.ValueOfBool(b))
+		return err
+	case bsontype.EmbeddedDocument:
+		return pc.decodeStruct(r, msg.Mutable(fields.ByName(structValueFieldName)).Message())
+	case bsontype.Array:
+		return pc.decodeList(r, msg.Mutable(fields.ByName(listValueFieldName)).Message())
+	}
+
+	switch pc.registry.UnmarshalOptions.NonJSONTypes {
+	case NonJSONToString:
+		if str, ok, err := readNonJSONString(r); ok || err != nil {
+			msg.Set(fields.ByName(stringValueFieldName), protoreflect.ValueOfString(str))
+			return err
+		}
+		return readExtendedJSON(r, msg)
+	case NonJSONToExtendedJSON:
+		return readExtendedJSON(r, msg)
+	}
+	return fmt.Errorf("BSON %s has no JSON counterpart", r.Type())
+}
+
+// setLargeInteger записывает в google.protobuf.Value msg целое i, которое не
+// помещается в double без потери точности, согласно
+// UnmarshalOptions.NonJSONTypes: десятичной строкой или объектом
+// {"$numberLong": "..."}.
+func (pc *protobufStructCodec) setLargeInteger(msg protoreflect.Message, i int64) error {
+	fields := msg.Descriptor().Fields()
+	str := strconv.FormatInt(i, 10)
+	switch pc.registry.UnmarshalOptions.NonJSONTypes {
+	case NonJSONToString:
+		msg.Set(fields.ByName(stringValueFieldName), protoreflect.ValueOfString(str))
+		return nil
+	case NonJSONToExtendedJSON:
+		// Relaxed Extended JSON пишет int64 числом, поэтому объект строится явно.
+		s := msg.Mutable(fields.ByName(structValueFieldName)).Message()
+		structFields := s.Mutable(s.Descriptor().Fields().ByName(structFieldsFieldName)).Map()
+		value := structFields.NewValue()
+		value.Message().Set(
+			value.Message().Descriptor().Fields().ByName(stringValueFieldName), protoreflect.ValueOfString(str),
+		)
+		structFields.Set(protoreflect.ValueOfString("$numberLong").MapKey(), value)
+		return nil
+	}
+	return fmt.Errorf("integer %d can't be stored in JSON number without loss of precision", i)
+}
+
+// readNonJSONString читает BSON типы, у которых есть естественное строковое
+// представление. Для остальных типов ok равен false, а значение не читается.
+func readNonJSONString(r bsonrw.ValueReader) (str string, ok bool, err error) {
+	switch r.Type() {
+	case bsontype.ObjectID:
+		oid, err := r.ReadObjectID()
+		return oid.Hex(), true, err
+	case bsontype.DateTime:
+		ms, err := r.ReadDateTime()
+		t := time.Unix(ms/1000, ms%1000*int64(time.Millisecond)).UTC()
+		return t.Format(time.RFC3339Nano), true, err
+	case bsontype.Decimal128:
+		d, err := r.ReadDecimal128()
+		return d.String(), true, err
+	case bsontype.Binary:
+		data, _, err := r.ReadBinary()
+		return base64.StdEncoding.EncodeToString(data), true, err
+	case bsontype.Symbol:
+		str, err := r.ReadSymbol()
+		return str, true, err
+	case bsontype.JavaScript:
+		str, err := r.ReadJavascript()
+		return str, true, err
+	}
+	return "", false, nil
+}
+
+// readExtendedJSON читает любое BSON значение и записывает его в
+// google.protobuf.Value msg в виде relaxed Extended JSON.
+func readExtendedJSON(r bsonrw.ValueReader, msg protoreflect.Message) error {
+	t, data, err := bsonrw.Copier{}.CopyValueToBytes(r)
+	if err != nil {
+		return err
+	}
+	const key = "v"
+	doc := bsoncore.BuildDocument(nil, bsoncore.AppendValueElement(
+		nil, key, bsoncore.Value{Type: t, Data: data},
+	))
+	jsonData, err := bson.MarshalExtJSON(bson.Raw(doc), false, false)
+	if err != nil {
+		return err
+	}
+	var elements map[string]json.RawMessage
+	if err = json.Unmarshal(jsonData, &elements); err != nil {
+		return err
+	}
+	return protojson.Unmarshal(elements[key], msg.Interface())
+}
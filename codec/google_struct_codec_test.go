@@ -0,0 +1,166 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestStruct(t *testing.T) {
+	assert := asrt.New(t)
+	metadata, err := structpb.NewStruct(map[string]interface{}{
+		"name":    "John",
+		"age":     42.0,
+		"admin":   true,
+		"manager": nil,
+		"tags":    []interface{}{"a", 1.0},
+		"address": map[string]interface{}{"city": "Moscow"},
+	})
+	assert.Nil(err)
+	msg := &gen.WellKnownTypes{
+		Struct:    metadata,
+		Value:     structpb.NewStringValue("value"),
+		ListValue: metadata.Fields["tags"].GetListValue(),
+	}
+
+	bsonData, err := Marshal(msg)
+	assert.Nil(err)
+	city, err := bson.Raw(bsonData).LookupErr("struct", "address", "city")
+	assert.Nil(err, "struct must be stored as a queryable document")
+	assert.Equal("Moscow", city.StringValue())
+	value, err := bson.Raw(bsonData).LookupErr("value")
+	assert.Nil(err)
+	assert.Equal("value", value.StringValue())
+	list, err := bson.Raw(bsonData).LookupErr("list_value")
+	assert.Nil(err)
+	assert.Equal(bsontype.Array, list.Type)
+
+	decoded := &gen.WellKnownTypes{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(msg.Struct, decoded.Struct))
+	assert.True(proto.Equal(msg.Value, decoded.Value))
+	assert.True(proto.Equal(msg.ListValue, decoded.ListValue))
+}
+
+func TestStructNonJSONTypes(t *testing.T) {
+	oid := primitive.NewObjectID()
+	date := time.Date(2021, 8, 23, 17, 34, 4, 0, time.UTC)
+	bsonData, err := bson.Marshal(bson.M{"struct": bson.M{
+		"id":      oid,
+		"date":    primitive.NewDateTimeFromTime(date),
+		"counter": int64(7),
+	}})
+	asrt.Nil(t, err)
+
+	cases := []struct {
+		policy NonJSONPolicy
+		id     *structpb.Value
+	}{
+		{policy: NonJSONToString, id: structpb.NewStringValue(oid.Hex())},
+		{policy: NonJSONToExtendedJSON, id: structpb.NewStructValue(&structpb.Struct{
+			Fields: map[string]*structpb.Value{"$oid": structpb.NewStringValue(oid.Hex())},
+		})},
+	}
+	for _, c := range cases {
+		assert := asrt.New(t)
+
+		decoded := &gen.WellKnownTypes{}
+		assert.Nil(UnmarshalOptions{NonJSONTypes: c.policy}.Unmarshal(bsonData, decoded))
+		fields := decoded.Struct.GetFields()
+		assert.True(proto.Equal(c.id, fields["id"]), "policy %d: got %v", c.policy, fields["id"])
+		assert.Equal(7.0, fields["counter"].GetNumberValue())
+		if c.policy == NonJSONToString {
+			assert.Equal("2021-08-23T17:34:04Z", fields["date"].GetStringValue())
+		}
+	}
+}
+
+func TestStructDynamic(t *testing.T) {
+	assert := asrt.New(t)
+	metadata, err := structpb.NewStruct(map[string]interface{}{
+		"name": "John",
+		"tags": []interface{}{"a", 1.0, nil},
+	})
+	assert.Nil(err)
+	msg := &gen.WellKnownTypes{Struct: metadata, Value: structpb.NewBoolValue(true)}
+	expected, err := Marshal(msg)
+	assert.Nil(err)
+
+	dynamic := dynamicpb.NewMessage(msg.ProtoReflect().Descriptor())
+	wireData, err := proto.Marshal(msg)
+	assert.Nil(err)
+	assert.Nil(proto.Unmarshal(wireData, dynamic))
+	bsonData, err := Marshal(dynamic)
+	assert.Nil(err)
+	assert.Equal(expected, bsonData)
+
+	decoded := dynamicpb.NewMessage(msg.ProtoReflect().Descriptor())
+	assert.Nil(Unmarshal(bsonData, decoded))
+	wireData, err = proto.Marshal(decoded)
+	assert.Nil(err)
+	roundTrip := &gen.WellKnownTypes{}
+	assert.Nil(proto.Unmarshal(wireData, roundTrip))
+	assert.True(proto.Equal(msg, roundTrip))
+}
+
+func TestStructDecodeErrorPath(t *testing.T) {
+	assert := asrt.New(t)
+	bsonData, err := bson.Marshal(bson.M{"struct": bson.M{
+		"list": bson.A{1, bson.M{"id": primitive.NewObjectID()}},
+	}})
+	assert.Nil(err)
+
+	err = UnmarshalOptions{NonJSONTypes: NonJSONError}.Unmarshal(bsonData, &gen.WellKnownTypes{})
+	var decodeErr *DecodeError
+	if assert.True(errors.As(err, &decodeErr)) {
+		assert.Equal("struct.list.1.id", decodeErr.Path)
+		assert.Equal(bsontype.ObjectID, decodeErr.BSONType)
+	}
+}
+
+func TestStructLargeIntegers(t *testing.T) {
+	const large = int64(1<<53 + 1)
+	bsonData, err := bson.Marshal(bson.M{"struct": bson.M{
+		"small": int64(1 << 53),
+		"large": large,
+	}})
+	asrt.Nil(t, err)
+
+	cases := []struct {
+		policy NonJSONPolicy
+		large  *structpb.Value
+	}{
+		{policy: NonJSONToString, large: structpb.NewStringValue("9007199254740993")},
+		{policy: NonJSONToExtendedJSON, large: structpb.NewStructValue(&structpb.Struct{
+			Fields: map[string]*structpb.Value{"$numberLong": structpb.NewStringValue("9007199254740993")},
+		})},
+		{policy: NonJSONError},
+	}
+	for _, c := range cases {
+		assert := asrt.New(t)
+
+		decoded := &gen.WellKnownTypes{}
+		err := UnmarshalOptions{NonJSONTypes: c.policy}.Unmarshal(bsonData, decoded)
+		if c.policy == NonJSONError {
+			var decodeErr *DecodeError
+			if assert.True(errors.As(err, &decodeErr)) {
+				assert.Equal("struct.large", decodeErr.Path)
+			}
+			continue
+		}
+		assert.Nil(err)
+		fields := decoded.Struct.GetFields()
+		assert.Equal(float64(1<<53), fields["small"].GetNumberValue())
+		assert.True(proto.Equal(c.large, fields["large"]), "policy %d: got %v", c.policy, fields["large"])
+	}
+}
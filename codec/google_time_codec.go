@@ -2,15 +2,53 @@ package codec
 
 import (
 	"fmt"
+	"math"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson/bsoncodec"
 	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"google.golang.org/protobuf/reflect/protoreflect"
-	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-// protobufBasicCodec кодирует/декодирует временные метки из google.protobuf.Timestamp
-// в BSON Timestamp.
+// TimestampMode задает BSON представление google.protobuf.Timestamp.
+type TimestampMode int
+
+const (
+	// TimestampDateTime - BSON DateTime, т.е. миллисекунды UTC. Такие значения
+	// понимают TTL индексы, запросы по диапазонам дат и Compass. Точность
+	// ниже миллисекунды теряется. Используется по умолчанию.
+	TimestampDateTime TimestampMode = iota
+	// TimestampDocument - поддокумент {seconds, nanos} без потери точности.
+	TimestampDocument
+	// TimestampRFC3339 - строка в формате RFC 3339 с наносекундами.
+	TimestampRFC3339
+	// TimestampBSON - BSON Timestamp, в котором секунды хранятся как время, а
+	// наносекунды - как инкремент. Служебный тип репликации Mongo, оставлен для
+	// совместимости с уже записанными данными. В Mongo инкремент - порядковый
+	// номер операции в пределах секунды, а не ее доля: значения, записанные
+	// сервером (например, $currentDate с $type: "timestamp"), декодируются с
+	// этим номером в качестве наносекунд, а инкремент больше 999999999 -
+	// ошибка. Не используйте этот режим для новых данных.
+	TimestampBSON
+)
+
+// Поля google.protobuf.Timestamp и google.protobuf.Duration.
+const (
+	secondsFieldName = "seconds"
+	nanosFieldName   = "nanos"
+)
+
+// Допустимый диапазон google.protobuf.Timestamp: от 0001-01-01T00:00:00Z до
+// 9999-12-31T23:59:59.999999999Z, как в timestamppb.CheckValid.
+const (
+	minTimestampSeconds = -62135596800
+	maxTimestampSeconds = 253402300799
+)
+
+// protobufTimestampCodec кодирует/декодирует временные метки из
+// google.protobuf.Timestamp в представление, заданное MarshalOptions.TimestampMode.
+// Декодирование понимает любое из представлений, независимо от настроек.
 type protobufTimestampCodec struct {
 	registry *CodecsRegistry
 }
@@ -27,22 +65,183 @@ func (pc *protobufTimestampCodec) EncodeValue(
 	if w == nil || !val.IsValid() {
 		return fmt.Errorf("nil writer, or invalid value")
 	}
-	msg := val.Message().Descriptor()
-	seconds := val.Message().Get(msg.Fields().Get(0)).Interface().(int64)
-	nanos := val.Message().Get(msg.Fields().Get(1)).Interface().(int32)
-	return w.WriteTimestamp(uint32(seconds), uint32(nanos))
+	seconds, nanos := getSecondsNanos(val.Message())
+	if err := checkTimestamp(seconds, nanos); err != nil {
+		return err
+	}
+
+	switch pc.registry.MarshalOptions.TimestampMode {
+	case TimestampDateTime:
+		return w.WriteDateTime(seconds*1000 + int64(nanos)/int64(time.Millisecond))
+	case TimestampDocument:
+		return writeSecondsNanos(w, seconds, nanos)
+	case TimestampRFC3339:
+		return w.WriteString(time.Unix(seconds, int64(nanos)).UTC().Format(time.RFC3339Nano))
+	case TimestampBSON:
+		if seconds < 0 || seconds > math.MaxUint32 {
+			return fmt.Errorf("timestamp seconds %d can't be stored as BSON Timestamp", seconds)
+		}
+		return w.WriteTimestamp(uint32(seconds), uint32(nanos))
+	}
+	return fmt.Errorf("unknown timestamp mode %d", pc.registry.MarshalOptions.TimestampMode)
 }
 
 func (pc *protobufTimestampCodec) DecodeValue(
 	_ bsoncodec.DecodeContext, r bsonrw.ValueReader, val protoreflect.Value,
 ) error {
-	ts, ok := val.Message().Interface().(*timestamppb.Timestamp)
-	if !ok {
-		msgFullName := val.Message().Descriptor().FullName()
-		return fmt.Errorf("message %s is not timestamppb.Timestamp", msgFullName)
+	var seconds int64
+	var nanos int32
+
+	switch r.Type() {
+	case bsontype.DateTime:
+		ms, err := r.ReadDateTime()
+		if err != nil {
+			return err
+		}
+		// Деление с округлением вниз, чтобы наносекунды даже у дат до 1970 года
+		// оставались неотрицательными.
+		seconds = ms / 1000
+		if ms%1000 < 0 {
+			seconds--
+		}
+		nanos = int32((ms - seconds*1000) * int64(time.Millisecond))
+	case bsontype.EmbeddedDocument:
+		var err error
+		if seconds, nanos, err = readSecondsNanos(r); err != nil {
+			return err
+		}
+	case bsontype.String:
+		str, err := r.ReadString()
+		if err != nil {
+			return err
+		}
+		t, err := time.Parse(time.RFC3339Nano, str)
+		if err != nil {
+			return err
+		}
+		seconds, nanos = t.Unix(), int32(t.Nanosecond())
+	case bsontype.Timestamp:
+		t, i, err := r.ReadTimestamp()
+		if err != nil {
+			return err
+		}
+		if i >= uint32(time.Second) {
+			return fmt.Errorf("timestamp increment %d can't be used as nanos", i)
+		}
+		seconds, nanos = int64(t), int32(i)
+	case bsontype.Null:
+		return r.ReadNull()
+	default:
+		return fmt.Errorf(
+			"can't decode %s from BSON %s", val.Message().Descriptor().FullName(), r.Type(),
+		)
+	}
+	// Декодированное значение проверяется так же, как при кодировании: ни
+	// поддокумент, ни строка, ни BSON Timestamp не ограничивают диапазон.
+	if err := checkTimestamp(seconds, nanos); err != nil {
+		return err
 	}
-	ts.Seconds = ts.GetSeconds()
-	ts.Nanos = int32(ts.GetSeconds())
+	setSecondsNanos(val.Message(), seconds, nanos)
 
 	return nil
 }
+
+// checkTimestamp проверяет, что seconds и nanos образуют корректный
+// google.protobuf.Timestamp. В отличие от timestamppb.CheckValid, подходит и
+// для динамических сообщений.
+func checkTimestamp(seconds int64, nanos int32) error {
+	if nanos < 0 || nanos >= int32(time.Second) {
+		return fmt.Errorf("timestamp nanos %d out of range", nanos)
+	}
+	if seconds < minTimestampSeconds || seconds > maxTimestampSeconds {
+		return fmt.Errorf("timestamp seconds %d out of range", seconds)
+	}
+	return nil
+}
+
+// getSecondsNanos возвращает поля seconds и nanos сообщений вида
+// google.protobuf.Timestamp и google.protobuf.Duration.
+func getSecondsNanos(msg protoreflect.Message) (int64, int32) {
+	fields := msg.Descriptor().Fields()
+	seconds := msg.Get(fields.ByName(secondsFieldName)).Int()
+	nanos := msg.Get(fields.ByName(nanosFieldName)).Int()
+	return seconds, int32(nanos)
+}
+
+// setSecondsNanos заполняет поля seconds и nanos сообщений вида
+// google.protobuf.Timestamp и google.protobuf.Duration.
+func setSecondsNanos(msg protoreflect.Message, seconds int64, nanos int32) {
+	fields := msg.Descriptor().Fields()
+	msg.Set(fields.ByName(secondsFieldName), protoreflect.ValueOfInt64(seconds))
+	msg.Set(fields.ByName(nanosFieldName), protoreflect.ValueOfInt32(nanos))
+}
+
+// writeSecondsNanos пишет поддокумент {seconds, nanos}.
+func writeSecondsNanos(w bsonrw.ValueWriter, seconds int64, nanos int32) error {
+	dw, err := w.WriteDocument()
+	if err != nil {
+		return err
+	}
+	vw, err := dw.WriteDocumentElement(secondsFieldName)
+	if err != nil {
+		return err
+	}
+	if err = vw.WriteInt64(seconds); err != nil {
+		return err
+	}
+	if vw, err = dw.WriteDocumentElement(nanosFieldName); err != nil {
+		return err
+	}
+	if err = vw.WriteInt32(nanos); err != nil {
+		return err
+	}
+	return dw.WriteDocumentEnd()
+}
+
+// readSecondsNanos читает поддокумент {seconds, nanos}. Числа могут быть
+// сохранены любым целочисленным BSON типом, отсутствующие поля равны нулю.
+func readSecondsNanos(r bsonrw.ValueReader) (seconds int64, nanos int32, err error) {
+	dr, err := r.ReadDocument()
+	if err != nil {
+		return 0, 0, err
+	}
+	for {
+		key, vr, err := dr.ReadElement()
+		if err == bsonrw.ErrEOD {
+			return seconds, nanos, nil
+		} else if err != nil {
+			return 0, 0, err
+		}
+		switch key {
+		case secondsFieldName:
+			if seconds, err = readInteger(vr); err != nil {
+				return 0, 0, err
+			}
+		case nanosFieldName:
+			n, err := readInteger(vr)
+			if err != nil {
+				return 0, 0, err
+			}
+			if n < math.MinInt32 || n > math.MaxInt32 {
+				return 0, 0, fmt.Errorf("nanos %d out of range", n)
+			}
+			nanos = int32(n)
+		default:
+			if err = vr.Skip(); err != nil {
+				return 0, 0, err
+			}
+		}
+	}
+}
+
+// readInteger читает целое число, сохраненное как BSON int32 или int64.
+func readInteger(r bsonrw.ValueReader) (int64, error) {
+	switch r.Type() {
+	case bsontype.Int32:
+		i, err := r.ReadInt32()
+		return int64(i), err
+	case bsontype.Int64:
+		return r.ReadInt64()
+	}
+	return 0, fmt.Errorf("expected integer, got BSON %s", r.Type())
+}
@@ -0,0 +1,89 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestTimestampModes(t *testing.T) {
+	ts := timestamppb.New(time.Date(1969, 7, 20, 20, 17, 40, 123456789, time.UTC))
+	msTs := timestamppb.New(ts.AsTime().Truncate(time.Millisecond))
+	cases := []struct {
+		mode     TimestampMode
+		bsonType bsontype.Type
+		decoded  *timestamppb.Timestamp
+	}{
+		{mode: TimestampDateTime, bsonType: bsontype.DateTime, decoded: msTs},
+		{mode: TimestampDocument, bsonType: bsontype.EmbeddedDocument, decoded: ts},
+		{mode: TimestampRFC3339, bsonType: bsontype.String, decoded: ts},
+	}
+	for _, c := range cases {
+		assert := asrt.New(t)
+
+		bsonData, err := MarshalOptions{TimestampMode: c.mode}.Marshal(&gen.Example{Ts: ts})
+		assert.Nil(err)
+		value, err := bson.Raw(bsonData).LookupErr("ts")
+		assert.Nil(err)
+		assert.Equal(c.bsonType, value.Type)
+
+		// Декодирование не зависит от режима кодирования.
+		decoded := &gen.Example{}
+		assert.Nil(Unmarshal(bsonData, decoded))
+		assert.True(proto.Equal(c.decoded, decoded.Ts), "mode %d: got %v", c.mode, decoded.Ts)
+	}
+}
+
+func TestTimestampLegacyMode(t *testing.T) {
+	assert := asrt.New(t)
+	ts := &timestamppb.Timestamp{Seconds: 1629740044, Nanos: 118}
+
+	bsonData, err := MarshalOptions{TimestampMode: TimestampBSON}.Marshal(&gen.Example{Ts: ts})
+	assert.Nil(err)
+	value, err := bson.Raw(bsonData).LookupErr("ts")
+	assert.Nil(err)
+	assert.Equal(bsontype.Timestamp, value.Type)
+
+	decoded := &gen.Example{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(ts, decoded.Ts))
+
+	_, err = MarshalOptions{TimestampMode: TimestampBSON}.Marshal(
+		&gen.Example{Ts: &timestamppb.Timestamp{Seconds: -1}},
+	)
+	assert.NotNil(err, "negative seconds don't fit into BSON Timestamp")
+}
+
+func TestTimestampDecodeValidation(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "negative nanos", value: bson.D{{Key: "seconds", Value: int64(1)}, {Key: "nanos", Value: int32(-1)}}},
+		{name: "too many nanos", value: bson.D{{Key: "nanos", Value: int32(time.Second)}}},
+		{name: "seconds after 9999 year", value: bson.D{{Key: "seconds", Value: int64(253402300800)}}},
+		{name: "year 0000", value: "0000-12-31T00:00:00Z"},
+		{name: "DateTime before 0001 year", value: primitive.DateTime(-62135596800001)},
+		{name: "increment over nanos", value: primitive.Timestamp{T: 1629740044, I: uint32(time.Second)}},
+	}
+	for _, c := range cases {
+		assert := asrt.New(t)
+
+		bsonData, err := bson.Marshal(bson.D{{Key: "ts", Value: c.value}})
+		assert.Nil(err)
+		var decodeErr *DecodeError
+		assert.True(errors.As(Unmarshal(bsonData, &gen.Example{}), &decodeErr), c.name)
+	}
+
+	_, err := Marshal(&gen.Example{Ts: &timestamppb.Timestamp{Seconds: 253402300800}})
+	asrt.NotNil(t, err, "timestamp after 9999 year is invalid")
+}
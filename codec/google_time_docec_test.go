@@ -43,19 +43,19 @@ var account = &a.Account{
 func TestEncodeDecode(t *testing.T) {
 	assert := asrt.New(t)
 
-	bsonData, err := EncodeDocument(account)
+	bsonData, err := Marshal(account)
 	assert.Nil(err)
 	assert.True(len(bsonData) > 0, "marshalled BSON bytes must be full.")
 
-	acc := &account
-	err = DecodeDocument(acc)
+	acc := &a.Account{}
+	err = Unmarshal(bsonData, acc)
 	assert.Nil(err, "error while decoding BSON data for account")
 	fmt.Println(acc)
 }
 
 func TestAccountTimestampDecoding(t *testing.T) {
 	acc := account
-	c := NewProtobufMongoCodec()
+	c := NewProtobufMongoCodec(Config{})
 	w, err := bsonrw.NewBSONValueWriter(bytes.NewBuffer([]byte{}))
 	if err != nil {
 		t.Fatal(err)
@@ -67,7 +67,7 @@ func TestAccountTimestampDecoding(t *testing.T) {
 }
 
 func TestAccountAccountDecoding(t *testing.T) {
-	c := NewProtobufMongoCodec()
+	c := NewProtobufMongoCodec(Config{})
 
 	buf := bytes.NewBuffer(nil)
 	w, err := bsonrw.NewBSONValueWriter(buf)
@@ -88,3 +88,18 @@ func TestAccountAccountDecoding(t *testing.T) {
 	err = c.DecodeValue(DefaultDecContext, reader, reflect.ValueOf(newAccount))
 	t.Fatal(err)
 }
+
+func TestAccountOneofModes(t *testing.T) {
+	assert := asrt.New(t)
+	for _, mode := range []OneofMode{OneofFlat, OneofWrapped, OneofDiscriminator} {
+		bsonData, err := MarshalOptions{OneofMode: mode}.Marshal(account)
+		assert.Nil(err)
+
+		acc := &a.Account{}
+		assert.Nil(Unmarshal(bsonData, acc))
+		if assert.Len(acc.Identities, 1) {
+			claims := acc.Identities[0].GetIdentityClaims().GetYandexIdentityClaims()
+			assert.Equal("john.doe@gmail.com", claims.GetEmail(), "mode %d", mode)
+		}
+	}
+}
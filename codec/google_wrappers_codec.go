@@ -0,0 +1,64 @@
+package codec
+
+import (
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// wrapperValueFieldName - имя единственного поля типов-оберток.
+const wrapperValueFieldName = "value"
+
+// wrapperTypes - типы-обертки google.protobuf.*Value, которые хранятся как
+// nullable скаляры.
+var wrapperTypes = map[protoreflect.FullName]bool{
+	ProtobufKindDoubleValue: true,
+	ProtobufKindFloatValue:  true,
+	ProtobufKindInt64Value:  true,
+	ProtobufKindUInt64Value: true,
+	ProtobufKindInt32Value:  true,
+	ProtobufKindUInt32Value: true,
+	ProtobufKindBoolValue:   true,
+	ProtobufKindStringValue: true,
+	ProtobufKindBytesValue:  true,
+}
+
+// protobufWrapperCodec кодирует/декодирует типы-обертки google.protobuf.*Value
+// как голые скаляры, как это делает protojson. Незаполненные обертки не пишутся
+// вовсе (см. protobufMessageCodec), либо пишутся как BSON null, если включен
+// MarshalOptions.EmitNullForUnset. При декодировании null и отсутствие ключа
+// одинаково означают незаполненное поле.
+type protobufWrapperCodec struct {
+	registry *CodecsRegistry
+}
+
+func newProtobufWrapperCodec(r *CodecsRegistry) *protobufWrapperCodec {
+	return &protobufWrapperCodec{
+		registry: r,
+	}
+}
+
+func (pc *protobufWrapperCodec) EncodeValue(
+	ctx bsoncodec.EncodeContext, w bsonrw.ValueWriter, val protoreflect.Value,
+) error {
+	msg := val.Message()
+	field := msg.Descriptor().Fields().ByName(wrapperValueFieldName)
+	return pc.registry.BasicCodec.encodeScalar(ctx, w, field, msg.Get(field))
+}
+
+func (pc *protobufWrapperCodec) DecodeValue(
+	ctx bsoncodec.DecodeContext, r bsonrw.ValueReader, val protoreflect.Value,
+) error {
+	if r.Type() == bsontype.Null {
+		return r.ReadNull()
+	}
+	msg := val.Message()
+	field := msg.Descriptor().Fields().ByName(wrapperValueFieldName)
+	value, err := pc.registry.BasicCodec.decodeScalar(ctx, r, field, msg.NewField(field))
+	if err != nil {
+		return err
+	}
+	msg.Set(field, value)
+	return nil
+}
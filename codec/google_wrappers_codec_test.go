@@ -0,0 +1,78 @@
+package codec
+
+import (
+	"testing"
+	"time"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestWrappers(t *testing.T) {
+	assert := asrt.New(t)
+	wrappers := &gen.WellKnownTypes{
+		Duration:    durationpb.New(time.Second),
+		DoubleValue: wrapperspb.Double(1.5),
+		FloatValue:  wrapperspb.Float(2.5),
+		Int64Value:  wrapperspb.Int64(-64),
+		Uint64Value: wrapperspb.UInt64(64),
+		Int32Value:  wrapperspb.Int32(-32),
+		Uint32Value: wrapperspb.UInt32(32),
+		BoolValue:   wrapperspb.Bool(false),
+		StringValue: wrapperspb.String(""),
+		BytesValue:  wrapperspb.Bytes([]byte{1, 2}),
+		Struct:      &structpb.Struct{},
+		Value:       structpb.NewNullValue(),
+		ListValue:   &structpb.ListValue{},
+		FieldMask:   &fieldmaskpb.FieldMask{},
+	}
+
+	bsonData, err := Marshal(wrappers)
+	assert.Nil(err)
+	expectedTypes := map[string]bsontype.Type{
+		"double_value": bsontype.Double,
+		"float_value":  bsontype.Double,
+		"int64_value":  bsontype.Int64,
+		"int32_value":  bsontype.Int32,
+		"bool_value":   bsontype.Boolean,
+		"string_value": bsontype.String,
+		"bytes_value":  bsontype.Binary,
+	}
+	for key, bsonType := range expectedTypes {
+		value, err := bson.Raw(bsonData).LookupErr(key)
+		assert.Nil(err, key)
+		assert.Equal(bsonType, value.Type, key)
+	}
+
+	decoded := &gen.WellKnownTypes{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(wrappers, decoded), "decoded message must be equal to the original")
+}
+
+func TestUnsetWrappers(t *testing.T) {
+	assert := asrt.New(t)
+
+	bsonData, err := Marshal(&gen.WellKnownTypes{})
+	assert.Nil(err)
+	_, err = bson.Raw(bsonData).LookupErr("string_value")
+	assert.NotNil(err, "unset wrapper must be omitted")
+
+	bsonData, err = MarshalOptions{EmitNullForUnset: true}.Marshal(&gen.WellKnownTypes{})
+	assert.Nil(err)
+	value, err := bson.Raw(bsonData).LookupErr("string_value")
+	assert.Nil(err)
+	assert.Equal(bsontype.Null, value.Type)
+
+	decoded := &gen.WellKnownTypes{StringValue: wrapperspb.String("must be cleared")}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.Nil(decoded.StringValue)
+	assert.Nil(decoded.Int64Value)
+}
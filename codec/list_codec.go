@@ -2,13 +2,12 @@ package codec
 
 import (
 	"fmt"
-	"go.uber.org/zap"
 	"io"
-	"log"
-	"reflect"
+	"strconv"
 
 	"go.mongodb.org/mongo-driver/bson/bsoncodec"
 	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.uber.org/multierr"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
@@ -25,97 +24,86 @@ func newProtobufListCodec(r *CodecsRegistry) *protobufListCodec {
 
 func (pc *protobufListCodec) EncodeValue(
 	ctx bsoncodec.EncodeContext, w bsonrw.ValueWriter, val protoreflect.Value,
+) error {
+	return pc.encodeFieldValue(ctx, w, nil, val)
+}
+
+// encodeFieldValue кодирует список val поля fd. Элементы кодируются с учетом
+// опций поля. fd может быть nil.
+func (pc *protobufListCodec) encodeFieldValue(
+	ctx bsoncodec.EncodeContext, w bsonrw.ValueWriter, fd protoreflect.FieldDescriptor,
+	val protoreflect.Value,
 ) error {
 	writer, err := w.WriteArray()
 	if err != nil {
 		return err
 	}
-	defer func() {
-		if err = writer.WriteArrayEnd(); err != nil {
-			Logger.Error("can't ending writing some BSON array", zap.Error(err))
-		}
-	}()
 
 	listValue := val.List()
+	// Незаполненный список пишется пустым массивом.
 	if !listValue.IsValid() {
-		log.Printf("list value %v is invalid", val)
-		return nil
+		return writer.WriteArrayEnd()
 	}
 
 	for i := 0; i < listValue.Len(); i++ {
-		fmt.Println("ITERATION ", i+1)
 		listItem := listValue.Get(i)
 		if !listItem.IsValid() {
 			continue
 		}
-		if err != nil {
-			return err
-		}
 		valueWriter, err := writer.WriteArrayElement()
 		if err != nil {
 			return err
 		}
-		codec, ok := pc.registry.GetCodecByValue(listItem)
-		if ok {
-			err = codec.EncodeValue(ctx, valueWriter, listItem)
-		} else {
-			err = pc.registry.BasicCodec.EncodeValue(ctx, valueWriter, listItem)
-		}
-		if err != nil {
-			return err
+		if err = pc.registry.encodeElement(ctx, valueWriter, fd, listItem); err != nil {
+			return wrapEncodeError(strconv.Itoa(i), fd, err)
 		}
 	}
 
-	return nil
+	return writer.WriteArrayEnd()
 }
 
-
 func (pc *protobufListCodec) DecodeValue(
-	ctx bsoncodec.DecodeContext, r bsonrw.DocumentReader, val protoreflect.Value,
+	ctx bsoncodec.DecodeContext, r bsonrw.ValueReader, val protoreflect.Value,
 ) error {
-	for {
-		elemName, valueReader, err := r.ReadElement()
-		valueReader.
-		if err != nil {
-			if err != bsonrw.ErrEOA
-		}
-		reader, err := r.ReadArray()
-		if err != nil {
-			return err
-		}
+	return pc.decodeFieldValue(ctx, r, nil, val)
+}
 
-		listValue := val.List()
-		if !listValue.IsValid() {
-			return fmt.Errorf("list value %v is invalid", val)
-		}
+// decodeFieldValue декодирует массив в список val поля fd. fd может быть nil.
+func (pc *protobufListCodec) decodeFieldValue(
+	ctx bsoncodec.DecodeContext, r bsonrw.ValueReader, fd protoreflect.FieldDescriptor,
+	val protoreflect.Value,
+) error {
+	listValue := val.List()
+	if !listValue.IsValid() {
+		return fmt.Errorf("list value %v is invalid", val)
+	}
+	reader, err := r.ReadArray()
+	if err != nil {
+		return err
+	}
 
-		for listValue.IsValid() {
-			valueReader, err := reader.ReadValue()
-			switch err {
-			case nil:
-			case bsonrw.ErrEOA, bsonrw.ErrEOD, io.EOF:
-				return nil
-			default:
-				log.Println("Can't read list element value, err=%", err)
-			}
-			listItem := listValue.NewElement()
-			codec, ok := pc.registry.GetCodecByValue(listItem)
-			if ok {
-				if err = codec.DecodeValue(ctx, valueReader, listItem); err != nil {
-					return err
-				}
-			} else {
-				basicValType := reflect.TypeOf(listItem.Interface())
-				basicValue, err := pc.registry.BasicCodec.DecodeValue(ctx, valueReader, basicValType)
-				if err != nil {
-					log.Println("Can't decode value", basicValue)
-					continue
-				}
-				listItem = protoreflect.ValueOf(basicValue)
+	var errs error
+	for index := 0; ; index++ {
+		valueReader, err := reader.ReadValue()
+		switch err {
+		case nil:
+		case bsonrw.ErrEOA, bsonrw.ErrEOD, io.EOF:
+			return errs
+		default:
+			return multierr.Append(errs, err)
+		}
+		if valueReader, err = pc.registry.detachValue(valueReader); err != nil {
+			return multierr.Append(errs, err)
+		}
+		bsonType := valueReader.Type()
+		listItem, err := pc.registry.decodeElement(ctx, valueReader, fd, listValue.NewElement())
+		if err != nil {
+			err = wrapDecodeErrors("", strconv.Itoa(index), fd, bsonType, err)
+			if !pc.registry.appendDecodeError(&errs, err) {
+				return errs
 			}
-			listValue.Append(listItem)
+			continue
 		}
+		listValue.Append(listItem)
 	}
-
-	return nil
 }
@@ -3,14 +3,15 @@ package codec
 import (
 	"encoding/json"
 	"fmt"
-	"go.uber.org/zap"
-	"log"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"go.mongodb.org/mongo-driver/bson/bsoncodec"
 	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.uber.org/multierr"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
@@ -72,7 +73,69 @@ func (pc *protobufMapCodec) encodeMapKey(key protoreflect.MapKey) (string, error
 	return fmt.Sprintf("%s%s%d", string(keyData), pc.keysDelimiter, keyReflectKindCode), nil
 }
 
-func (pc *protobufMapCodec) decodeMapKey(strKey string) protoreflect.MapKey {
+// decodeMapKey декодирует ключ мапы strKey в значение вида поля ключа
+// keyField: строковые ключи берутся как есть, остальные разбираются строго, с
+// необязательным суффиксом вида типа, который пишет encodeMapKey. keyField
+// может быть nil, тогда вид ключа угадывается по суффиксу.
+func (pc *protobufMapCodec) decodeMapKey(
+	strKey string, keyField protoreflect.FieldDescriptor,
+) (protoreflect.MapKey, error) {
+	if keyField == nil {
+		return pc.guessMapKey(strKey), nil
+	}
+	kind := keyField.Kind()
+	if kind == protoreflect.StringKind {
+		return protoreflect.ValueOfString(strKey).MapKey(), nil
+	}
+	keyData := strKey
+	if delimiterIndex := strings.LastIndex(strKey, pc.keysDelimiter); delimiterIndex != -1 {
+		keyData = strKey[:delimiterIndex]
+		expected := reflect.TypeOf(keyField.Default().Interface()).Kind()
+		if strKey[delimiterIndex+1:] != strconv.Itoa(int(expected)) {
+			return protoreflect.MapKey{}, fmt.Errorf("map key %q is not %s", strKey, kind)
+		}
+	}
+	var value protoreflect.Value
+	switch kind {
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(keyData)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("map key %q is not %s", strKey, kind)
+		}
+		value = protoreflect.ValueOfBool(b)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(keyData, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("map key %q is not %s", strKey, kind)
+		}
+		value = protoreflect.ValueOfInt32(int32(n))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(keyData, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("map key %q is not %s", strKey, kind)
+		}
+		value = protoreflect.ValueOfInt64(n)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(keyData, 10, 32)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("map key %q is not %s", strKey, kind)
+		}
+		value = protoreflect.ValueOfUint32(uint32(n))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(keyData, 10, 64)
+		if err != nil {
+			return protoreflect.MapKey{}, fmt.Errorf("map key %q is not %s", strKey, kind)
+		}
+		value = protoreflect.ValueOfUint64(n)
+	default:
+		return protoreflect.MapKey{}, fmt.Errorf("unsupported map key kind %s", kind)
+	}
+	return value.MapKey(), nil
+}
+
+// guessMapKey угадывает вид ключа мапы strKey по суффиксу, который пишет
+// encodeMapKey. Используется, только когда поле мапы неизвестно.
+func (pc *protobufMapCodec) guessMapKey(strKey string) protoreflect.MapKey {
 	// Разбиваем данные ключа на части - в левой будет сам JSON сериализованный
 	// ключ, а в правой - идентификатор типа значения данного ключа.
 	delimiterIndex := strings.LastIndex(strKey, pc.keysDelimiter)
@@ -89,7 +152,10 @@ func (pc *protobufMapCodec) decodeMapKey(strKey string) protoreflect.MapKey {
 		return protoreflect.ValueOf(strKey).MapKey()
 	}
 
-	keyType := basicReflectTypesByKind[reflect.Kind(keyReflectKindCode)]
+	keyType, ok := basicReflectTypesByKind[reflect.Kind(keyReflectKindCode)]
+	if !ok {
+		return protoreflect.ValueOf(strKey).MapKey()
+	}
 	key := reflect.New(keyType)
 	// Если произошла ошибка при анмаршалинге, значит ключ изначально был в строковом
 	// значении - возвращаем его, как есть.
@@ -100,82 +166,180 @@ func (pc *protobufMapCodec) decodeMapKey(strKey string) protoreflect.MapKey {
 	return protoreflect.ValueOf(key.Elem().Interface()).MapKey()
 }
 
+// rangeMap обходит элементы мапы m. Если порядок полей детерминированный, то
+// элементы обходятся по возрастанию ключей, иначе - в порядке итерации мапы.
+func (pc *protobufMapCodec) rangeMap(
+	m protoreflect.Map, f func(protoreflect.MapKey, protoreflect.Value) bool,
+) {
+	if pc.registry.MarshalOptions.FieldOrder == AnyOrder {
+		m.Range(f)
+		return
+	}
+	keys := make([]protoreflect.MapKey, 0, m.Len())
+	m.Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		return lessMapKeys(keys[i], keys[j])
+	})
+	for _, key := range keys {
+		if !f(key, m.Get(key)) {
+			return
+		}
+	}
+}
+
+// lessMapKeys сравнивает ключи мапы одного типа в их естественном порядке:
+// числа - по значению, строки - лексикографически, false идет раньше true.
+func lessMapKeys(a, b protoreflect.MapKey) bool {
+	switch a.Interface().(type) {
+	case bool:
+		return !a.Bool() && b.Bool()
+	case int32, int64:
+		return a.Int() < b.Int()
+	case uint32, uint64:
+		return a.Uint() < b.Uint()
+	case string:
+		return a.String() < b.String()
+	}
+	return false
+}
+
+// mapKeyField возвращает поле ключей мапы fd, либо nil, если fd неизвестно.
+func mapKeyField(fd protoreflect.FieldDescriptor) protoreflect.FieldDescriptor {
+	if fd == nil {
+		return nil
+	}
+	return fd.MapKey()
+}
+
+// mapValueField возвращает поле значений мапы fd, либо nil, если fd неизвестно.
+func mapValueField(fd protoreflect.FieldDescriptor) protoreflect.FieldDescriptor {
+	if fd == nil {
+		return nil
+	}
+	return fd.MapValue()
+}
+
 func (pc *protobufMapCodec) EncodeValue(
-	ctx bsoncodec.EncodeContext, docMap bsonrw.DocumentWriter, val protoreflect.Value,
+	ctx bsoncodec.EncodeContext, w bsonrw.ValueWriter, val protoreflect.Value,
 ) error {
+	return pc.encodeFieldValue(ctx, w, nil, val)
+}
+
+// encodeFieldValue кодирует мапу val поля fd. Значения кодируются с учетом
+// опций поля. fd может быть nil.
+func (pc *protobufMapCodec) encodeFieldValue(
+	ctx bsoncodec.EncodeContext, w bsonrw.ValueWriter, fd protoreflect.FieldDescriptor,
+	val protoreflect.Value,
+) error {
+	valueField := mapValueField(fd)
+	// Невалидная мапа - это незаполненное поле, она кодируется пустым документом.
 	mapValue := val.Map()
-	if !mapValue.IsValid() {
-		return fmt.Errorf("map value is invalid: %v", mapValue)
+	docMap, err := w.WriteDocument()
+	if err != nil {
+		return err
 	}
 
-	mapValue.Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+	var rangeErr error
+	pc.rangeMap(mapValue, func(key protoreflect.MapKey, value protoreflect.Value) bool {
 		strKey, err := pc.encodeMapKey(key)
 		if err != nil {
-			log.Println("Can't encode key", key)
+			rangeErr = wrapEncodeError(key.String(), valueField, err)
 			return false
 		}
 		valueWriter, err := docMap.WriteDocumentElement(strKey)
 		if err != nil {
-			log.Println("Can't write document element by key", strKey)
+			rangeErr = err
 			return false
 		}
-
-		codec, ok := pc.registry.GetCodecByValue(value)
-		if ok {
-			err = codec.EncodeValue(ctx, docMap, value)
-		} else {
-			err = pc.registry.BasicCodec.EncodeValue(ctx, valueWriter, value)
-		}
-		if err != nil {
-			log.Println("Can't encode value", value)
+		if err = pc.registry.encodeElement(ctx, valueWriter, valueField, value); err != nil {
+			rangeErr = wrapEncodeError(strKey, valueField, err)
 			return false
 		}
-
 		return true
 	})
+	if rangeErr != nil {
+		return rangeErr
+	}
 
-	return nil
+	return docMap.WriteDocumentEnd()
 }
 
 func (pc *protobufMapCodec) DecodeValue(
-	ctx bsoncodec.DecodeContext, mapReader bsonrw.DocumentReader, val protoreflect.Value,
+	ctx bsoncodec.DecodeContext, r bsonrw.ValueReader, val protoreflect.Value,
 ) error {
+	return pc.decodeFieldValue(ctx, r, nil, val)
+}
+
+// decodeFieldValue декодирует документ в мапу val поля fd. fd может быть nil.
+func (pc *protobufMapCodec) decodeFieldValue(
+	ctx bsoncodec.DecodeContext, r bsonrw.ValueReader, fd protoreflect.FieldDescriptor,
+	val protoreflect.Value,
+) error {
+	valueField := mapValueField(fd)
 	mapValue := val.Map()
 	if !mapValue.IsValid() {
 		return fmt.Errorf("map value is invalid: %v", mapValue)
 	}
+	mapReader, err := r.ReadDocument()
+	if err != nil {
+		return err
+	}
 
+	strict := pc.registry.UnmarshalOptions.Strict
+	// Ключи сравниваются после разбора, т.к. "1" и "1|i" - один ключ мапы.
+	seenKeys := make(map[interface{}]bool)
+	var errs error
 	for {
 		strKey, valueReader, err := mapReader.ReadElement()
-		Logger.Info("From Proto map to BSON doc iteration",
-			zap.Error(err),
-			zap.String("key", strKey))
-		if err != nil {
+		if err == bsonrw.ErrEOD {
 			break
+		} else if err != nil {
+			return multierr.Append(errs, err)
+		}
+		pc.registry.logger().Debug("From Proto map to BSON doc iteration", "key", strKey)
+		if valueReader, err = pc.registry.detachValue(valueReader); err != nil {
+			return multierr.Append(errs, err)
 		}
 
-		mapKey := pc.decodeMapKey(strKey)
-		value := mapValue.NewValue()
-		codec, ok := pc.registry.GetCodecByValue(value)
-		// Если нашли кодек для нужного типа значения мапы, то используем его.
-		if ok {
-			if err = codec.DecodeValue(ctx, valueReader, value); err != nil {
-				log.Println("Can't decode value", value)
-				continue
+		bsonType := valueReader.Type()
+		if strict && !utf8.ValidString(strKey) {
+			err = wrapDecodeError(strKey, valueField, bsonType, fmt.Errorf("map key is not valid UTF-8"))
+			if !pc.registry.appendDecodeError(&errs, err) {
+				return errs
 			}
-		} else {
-			// Иначе - исползуем стандартный кодеровщик базовых типов.
-			basicValType := reflect.TypeOf(value.Interface())
-			basicValue, err := pc.registry.BasicCodec.DecodeValue(ctx, valueReader, basicValType)
-			if err != nil {
-				log.Println("Can't decode value", basicValue)
+			continue
+		}
+		mapKey, err := pc.decodeMapKey(strKey, mapKeyField(fd))
+		if err != nil {
+			err = wrapDecodeError(strKey, mapKeyField(fd), bsonType, err)
+			if !pc.registry.appendDecodeError(&errs, err) {
+				return errs
+			}
+			continue
+		}
+		if strict {
+			if seenKeys[mapKey.Interface()] {
+				err = &DecodeError{Path: strKey, BSONType: bsonType, Err: fmt.Errorf("duplicate key")}
+				if !pc.registry.appendDecodeError(&errs, err) {
+					return errs
+				}
 				continue
 			}
-			value = protoreflect.ValueOf(basicValue)
+			seenKeys[mapKey.Interface()] = true
+		}
+		value, err := pc.registry.decodeElement(ctx, valueReader, valueField, mapValue.NewValue())
+		if err != nil {
+			err = wrapDecodeErrors("", strKey, valueField, bsonType, err)
+			if !pc.registry.appendDecodeError(&errs, err) {
+				return errs
+			}
+			continue
 		}
-
 		mapValue.Set(mapKey, value)
 	}
 
-	return nil
+	return errs
 }
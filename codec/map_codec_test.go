@@ -0,0 +1,44 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/protobuf/proto"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestMapKeys(t *testing.T) {
+	assert := asrt.New(t)
+	msg := &gen.Scalars{
+		// Строковые ключи, похожие на закодированные числовые, остаются строками.
+		Uint64Map: map[string]uint64{"5|5": 1, "12|2": 2, "plain": 3},
+		Int32Map:  map[int32]string{-7: "a", 42: "b"},
+		BoolMap:   map[bool]int32{true: 1, false: 0},
+	}
+	bsonData, err := Marshal(msg)
+	assert.Nil(err)
+	decoded := &gen.Scalars{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(msg, decoded), "decoded message must be equal to the original")
+
+	// Числовые ключи без суффикса тоже принимаются.
+	bsonData, err = bson.Marshal(bson.D{{Key: "int32_map", Value: bson.D{{Key: "3", Value: "c"}}}})
+	assert.Nil(err)
+	decoded = &gen.Scalars{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.Equal(map[int32]string{3: "c"}, decoded.Int32Map)
+
+	for _, key := range []string{"x", "5|6", "1.5|5", "99999999999|5"} {
+		bsonData, err = bson.Marshal(bson.D{{Key: "int32_map", Value: bson.D{{Key: key, Value: "c"}}}})
+		assert.Nil(err)
+		err = Unmarshal(bsonData, &gen.Scalars{})
+		var decodeErr *DecodeError
+		if assert.True(errors.As(err, &decodeErr), key) {
+			assert.Equal("int32_map."+key, decodeErr.Path)
+		}
+	}
+}
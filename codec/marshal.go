@@ -0,0 +1,238 @@
+package codec
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+	"google.golang.org/protobuf/proto"
+)
+
+// Marshal кодирует сообщение m в BSON документ с настройками по умолчанию.
+func Marshal(m proto.Message) ([]byte, error) {
+	return MarshalOptions{}.Marshal(m)
+}
+
+// Unmarshal декодирует BSON документ b в сообщение m с настройками по умолчанию.
+func Unmarshal(b []byte, m proto.Message) error {
+	return UnmarshalOptions{}.Unmarshal(b, m)
+}
+
+// FieldOrder задает порядок, в котором поля сообщения и элементы мап пишутся в
+// BSON документ.
+type FieldOrder int
+
+const (
+	// NumberOrder - поля пишутся по возрастанию их номеров, элементы мап - по
+	// возрастанию ключей. Используется по умолчанию.
+	NumberOrder FieldOrder = iota
+	// DeclarationOrder - поля пишутся в порядке объявления в .proto файле,
+	// элементы мап - по возрастанию ключей.
+	DeclarationOrder
+	// AnyOrder - порядок не гарантируется: поля пишутся в порядке объявления,
+	// а элементы мап - в порядке итерации, без затрат на сортировку. Одно и то же
+	// сообщение может давать разные байты от запуска к запуску.
+	AnyOrder
+)
+
+// MarshalOptions - настройки кодирования сообщений Protobuf'а в BSON. Повторяют
+// по духу protojson.MarshalOptions: каждый вызов Marshal использует только
+// переданные настройки и не зависит от глобального состояния пакета.
+type MarshalOptions struct {
+	// AllowPartial разрешает кодировать сообщения, в которых не заполнены
+	// обязательные (required) поля.
+	AllowPartial bool
+	// FieldOrder задает порядок полей в документе. По умолчанию кодирование
+	// детерминированное: поля идут по возрастанию номеров.
+	FieldOrder FieldOrder
+	// FieldNaming задает ключи полей в документе. По умолчанию - ProtoNames.
+	FieldNaming NamingStrategy
+	// TimestampMode задает представление google.protobuf.Timestamp. По
+	// умолчанию - BSON DateTime.
+	TimestampMode TimestampMode
+	// DurationMode задает представление google.protobuf.Duration. По умолчанию
+	// - int64 наносекунды.
+	DurationMode DurationMode
+	// EmitUnpopulated пишет незаполненные поля: поля с присутствием (сообщения,
+	// optional, обертки) - как BSON null, остальные - нулевыми значениями.
+	// Невыбранные поля oneof'ов не пишутся. По умолчанию незаполненные поля
+	// пропускаются, как в protojson.
+	EmitUnpopulated bool
+	// EmitNullForUnset пишет незаполненные поля с присутствием как BSON null,
+	// вместо того чтобы их пропускать. Поля без присутствия по-прежнему
+	// пропускаются.
+	EmitNullForUnset bool
+	// Resolver ищет типы сообщений, упакованных в google.protobuf.Any. По
+	// умолчанию - protoregistry.GlobalTypes.
+	Resolver TypeResolver
+	// FieldMaskMode задает представление google.protobuf.FieldMask. По
+	// умолчанию - массив путей.
+	FieldMaskMode FieldMaskMode
+	// Uint64Mode задает представление uint64 и fixed64 значений. Опция поля
+	// (proto_bson.field).uint64_mode имеет приоритет. При декодировании
+	// принимается любое представление.
+	Uint64Mode Uint64Mode
+	// UseEnumNumbers пишет значения enum'ов номерами, а не именами. Нужен для
+	// совместимости с документами, записанными раньше. При декодировании
+	// принимаются и имена, и номера.
+	UseEnumNumbers bool
+	// OneofMode задает, как хранится выбранное поле oneof'а. По умолчанию -
+	// OneofFlat. При декодировании принимается любой режим.
+	OneofMode OneofMode
+}
+
+// Marshal кодирует сообщение m в BSON документ.
+func (o MarshalOptions) Marshal(m proto.Message) ([]byte, error) {
+	return o.MarshalAppend(nil, m)
+}
+
+// MarshalAppend кодирует сообщение m в BSON документ и дописывает его к b.
+func (o MarshalOptions) MarshalAppend(b []byte, m proto.Message) ([]byte, error) {
+	// Пустое сообщение кодируется пустым документом, т.к. BSON не допускает
+	// null на верхнем уровне.
+	if m == nil || !m.ProtoReflect().IsValid() {
+		return bsoncore.BuildDocument(b), nil
+	}
+	if !o.AllowPartial {
+		if err := proto.CheckInitialized(m); err != nil {
+			return nil, err
+		}
+	}
+
+	pc := &ProtobufMongoCodec{Registry: marshalRegistry(o)}
+
+	buf := bytes.NewBuffer(b)
+	writer, err := bsonrw.NewBSONValueWriter(buf)
+	if err != nil {
+		return nil, err
+	}
+	if err = pc.EncodeValue(DefaultEncContext, writer, reflect.ValueOf(m)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalOptions - настройки декодирования BSON документов в сообщения
+// Protobuf'а, по аналогии с protojson.UnmarshalOptions.
+type UnmarshalOptions struct {
+	// AllowPartial разрешает оставлять обязательные (required) поля
+	// незаполненными после декодирования.
+	AllowPartial bool
+	// FieldNaming задает ключи, по которым ищутся поля сообщения. Должна
+	// совпадать со стратегией, с которой документ был закодирован. По умолчанию
+	// - ProtoNames.
+	FieldNaming NamingStrategy
+	// DurationMode задает единицы, в которых записаны google.protobuf.Duration,
+	// сохраненные целыми числами: DurationMillis - миллисекунды, остальные
+	// режимы - наносекунды. Прочие представления распознаются по BSON типу.
+	DurationMode DurationMode
+	// NonJSONTypes определяет, во что превращаются BSON типы, которых нет в
+	// JSON (ObjectID, DateTime, ...), при декодировании в google.protobuf.Value.
+	// По умолчанию - в строки.
+	NonJSONTypes NonJSONPolicy
+	// Resolver ищет типы сообщений, упакованных в google.protobuf.Any. По
+	// умолчанию - protoregistry.GlobalTypes. Если тип из ключа "@type" не
+	// найден, то декодирование завершается ошибкой: без описания типа документ
+	// нельзя превратить в двоичное значение Any. Двоичная форма {type_url,
+	// value} декодируется без Resolver'а.
+	Resolver TypeResolver
+	// UnknownEnum определяет, что делать с именами и номерами, которых нет в
+	// описании enum'а. По умолчанию неизвестные номера сохраняются, а
+	// неизвестные имена - ошибка.
+	UnknownEnum UnknownEnumPolicy
+	// NumericCoercion определяет, какие BSON типы принимаются для числовых и
+	// логических полей. По умолчанию - только приведения без потерь.
+	NumericCoercion NumericCoercion
+	// PreserveUnknown сохраняет элементы документа, для которых в сообщении нет
+	// полей, в его неизвестных полях (unknown fields), так что при следующем
+	// кодировании они будут записаны обратно. Нужен, когда документ читает
+	// сервис со старой схемой, чтобы он не затер новые поля. По умолчанию такие
+	// элементы пропускаются. Элементы хранятся под номером поля 536870911,
+	// который нельзя объявлять в хранимых сообщениях, и попадают в результат
+	// proto.Marshal.
+	PreserveUnknown bool
+	// Strict прерывает декодирование с ошибкой *DecodeError при неизвестных и
+	// повторяющихся ключах, ключах мап и строках, которые не являются
+	// корректным UTF-8. Имеет приоритет над PreserveUnknown. По умолчанию такие
+	// значения пропускаются. Строковые поля в строгом режиме принимают только
+	// BSON string (UUID поля - еще и Binary UUID), а байтовые - только Binary,
+	// без приведения ObjectID, Symbol и т.п. Прочие BSON типы, которые не
+	// подходят виду поля, - ошибка в любом режиме. Повторяющиеся ключи и UTF-8
+	// проверяются и в мапах, и в google.protobuf.Struct. Числовые и логические
+	// поля принимают BSON типы согласно NumericCoercion, независимо от Strict:
+	// например, double 7.0 в поле int32 допустим и в строгом режиме.
+	Strict bool
+	// CollectErrors продолжает декодирование после ошибок в полях, элементах
+	// списков и значениях мап: значение пропускается, а ошибка добавляется к
+	// остальным. Unmarshal возвращает все *DecodeError документа, объединенные
+	// multierr (их можно получить через multierr.Errors), а в сообщении
+	// заполнены все поля, которые удалось декодировать. По умолчанию
+	// декодирование прерывается на первой ошибке.
+	CollectErrors bool
+}
+
+// Unmarshal декодирует BSON документ b в сообщение m. Перед декодированием
+// сообщение сбрасывается, т.е. поля, которых нет в b, останутся пустыми.
+func (o UnmarshalOptions) Unmarshal(b []byte, m proto.Message) error {
+	proto.Reset(m)
+
+	pc := &ProtobufMongoCodec{Registry: unmarshalRegistry(o)}
+
+	reader := bsonrw.NewBSONDocumentReader(b)
+	if err := pc.DecodeValue(DefaultDecContext, reader, reflect.ValueOf(m)); err != nil {
+		return err
+	}
+	if !o.AllowPartial {
+		return proto.CheckInitialized(m)
+	}
+	return nil
+}
+
+// Реестры, общие для вызовов Marshal и Unmarshal с одинаковыми настройками.
+// Кэшируются только настройки со встроенной стратегией именования и Resolver'ом
+// по умолчанию: таких настроек конечное число, и их можно сравнивать.
+var marshalRegistries, unmarshalRegistries sync.Map
+
+// marshalRegistry возвращает реестр кодеков с настройками кодирования o.
+func marshalRegistry(o MarshalOptions) *CodecsRegistry {
+	if !isBuiltinNaming(o.FieldNaming) || o.Resolver != nil {
+		r := DefaultCodecsRegistry()
+		r.MarshalOptions = o
+		return r
+	}
+	if r, ok := marshalRegistries.Load(o); ok {
+		return r.(*CodecsRegistry)
+	}
+	r := DefaultCodecsRegistry()
+	r.MarshalOptions = o
+	cached, _ := marshalRegistries.LoadOrStore(o, r)
+	return cached.(*CodecsRegistry)
+}
+
+// unmarshalRegistry возвращает реестр кодеков с настройками декодирования o.
+func unmarshalRegistry(o UnmarshalOptions) *CodecsRegistry {
+	if !isBuiltinNaming(o.FieldNaming) || o.Resolver != nil {
+		r := DefaultCodecsRegistry()
+		r.UnmarshalOptions = o
+		return r
+	}
+	if r, ok := unmarshalRegistries.Load(o); ok {
+		return r.(*CodecsRegistry)
+	}
+	r := DefaultCodecsRegistry()
+	r.UnmarshalOptions = o
+	cached, _ := unmarshalRegistries.LoadOrStore(o, r)
+	return cached.(*CodecsRegistry)
+}
+
+// isBuiltinNaming сообщает, является ли naming одной из стратегий пакета.
+// Пользовательские стратегии могут быть несравнимыми, например, NamingFunc.
+func isBuiltinNaming(naming NamingStrategy) bool {
+	switch naming.(type) {
+	case nil, protoNames, jsonNames, fieldNumbers:
+		return true
+	}
+	return false
+}
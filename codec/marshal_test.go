@@ -0,0 +1,110 @@
+package codec
+
+import (
+	"sync"
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	assert := asrt.New(t)
+
+	bsonData, err := Marshal(example)
+	assert.Nil(err)
+	assert.Nil(bson.Raw(bsonData).Validate())
+
+	decoded := &gen.Example{StringField: "must be reset"}
+	err = Unmarshal(bsonData, decoded)
+	assert.Nil(err)
+	assert.True(proto.Equal(example, decoded), "decoded message must be equal to the original")
+}
+
+func TestMarshalAppend(t *testing.T) {
+	assert := asrt.New(t)
+
+	prefix := []byte("prefix")
+	bsonData, err := MarshalOptions{}.MarshalAppend(prefix, example)
+	assert.Nil(err)
+	assert.Equal(prefix, bsonData[:len(prefix)])
+
+	single, err := Marshal(example)
+	assert.Nil(err)
+	assert.Equal(len(prefix)+len(single), len(bsonData))
+}
+
+func TestMarshalNil(t *testing.T) {
+	assert := asrt.New(t)
+
+	bsonData, err := Marshal((*gen.Example)(nil))
+	assert.Nil(err)
+	assert.Equal([]byte{5, 0, 0, 0, 0}, bsonData)
+}
+
+func TestMarshalDeterministic(t *testing.T) {
+	assert := asrt.New(t)
+
+	expected, err := Marshal(example)
+	assert.Nil(err)
+	for i := 0; i < 100; i++ {
+		bsonData, err := Marshal(example)
+		assert.Nil(err)
+		assert.Equal(expected, bsonData, "encoding must be repeatable")
+	}
+
+	elements, err := bson.Raw(expected).Elements()
+	assert.Nil(err)
+	keys := make([]string, 0, len(elements))
+	for _, element := range elements {
+		keys = append(keys, element.Key())
+	}
+	assert.Equal([]string{
+		"string_field", "projects", "any_field", "str_array", "ts",
+		"enum_field", "int64_field", "nested_message",
+	}, keys, "fields must be ordered by field numbers")
+
+	projects, err := bson.Raw(expected).LookupErr("projects")
+	assert.Nil(err)
+	projectsElements, err := projects.Document().Elements()
+	assert.Nil(err)
+	assert.Equal("first", projectsElements[0].Key())
+	assert.Equal("second", projectsElements[1].Key())
+}
+
+func TestMarshalDeclarationOrder(t *testing.T) {
+	assert := asrt.New(t)
+
+	bsonData, err := MarshalOptions{FieldOrder: DeclarationOrder}.Marshal(example)
+	assert.Nil(err)
+	elements, err := bson.Raw(bsonData).Elements()
+	assert.Nil(err)
+	fields := example.ProtoReflect().Descriptor().Fields()
+	assert.Equal(string(fields.Get(0).Name()), elements[0].Key())
+}
+
+func TestSharedRegistries(t *testing.T) {
+	assert := asrt.New(t)
+	assert.True(marshalRegistry(MarshalOptions{}) == marshalRegistry(MarshalOptions{}))
+	assert.True(unmarshalRegistry(UnmarshalOptions{Strict: true}) == unmarshalRegistry(UnmarshalOptions{Strict: true}))
+	custom := NamingFunc(func(field pref.FieldDescriptor) string { return string(field.Name()) })
+	assert.False(marshalRegistry(MarshalOptions{FieldNaming: custom}) == marshalRegistry(MarshalOptions{FieldNaming: custom}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bsonData, err := Marshal(example)
+			assert.Nil(err)
+			decoded := &gen.Example{}
+			assert.Nil(Unmarshal(bsonData, decoded))
+			assert.True(proto.Equal(example, decoded))
+		}()
+	}
+	wg.Wait()
+}
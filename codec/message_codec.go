@@ -1,50 +1,20 @@
 package codec
 
 import (
-	"bytes"
-	"reflect"
+	"errors"
+	"fmt"
+	"sort"
 
-	"go.uber.org/zap"
+	"go.uber.org/multierr"
 
 	pref "google.golang.org/protobuf/reflect/protoreflect"
 
 	"go.mongodb.org/mongo-driver/bson/bsoncodec"
 	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"google.golang.org/protobuf/proto"
 )
 
-func EncodeDocument(doc interface{}) ([]byte, error) {
-	bsonBuf := bytes.NewBuffer(nil)
-	writer, err := bsonrw.NewBSONValueWriter(bsonBuf)
-	if err != nil {
-		return nil, err
-	}
-	docWriter, err := writer.WriteDocument()
-	if err != nil {
-		return nil, err
-	}
-	pc := NewProtobufMongoCodec()
-	if err := pc.EncodeValue(DefaultEncContext, docWriter, reflect.ValueOf(doc)); err != nil {
-		return nil, err
-	}
-	return bsonBuf.Bytes(), nil
-}
-
-func DecodeDocument(doc interface{}) (err error) {
-	bsonBytes, err := EncodeDocument(doc)
-	if err != nil {
-		return err
-	}
-
-	reader := bsonrw.NewBSONDocumentReader(bsonBytes)
-	pc := NewProtobufMongoCodec()
-	err = pc.DecodeValue(DefaultDecContext, reader, reflect.ValueOf(doc))
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
 // protobufMessageCodec кодек для сообщений Protobuf'а.
 type protobufMessageCodec struct {
 	registry *CodecsRegistry
@@ -56,149 +26,301 @@ func newProtobufMessageCodec(r *CodecsRegistry) *protobufMessageCodec {
 	}
 }
 
-func (pc *protobufMessageCodec) fieldKey(field pref.FieldDescriptor) string {
-	return string(field.Name())
-}
-
-func (pc *protobufMessageCodec) getMessageFields(msg proto.Message) map[string]pref.FieldDescriptor {
-	fields := make(map[string]pref.FieldDescriptor)
+// getMessageFields возвращает поля сообщения, которые нужно закодировать, в
+// порядке, заданном MarshalOptions.FieldOrder. Из каждого oneof'а берется только
+// выбранное поле.
+func (pc *protobufMessageCodec) getMessageFields(msg proto.Message) []pref.FieldDescriptor {
 	reflectMessage := msg.ProtoReflect()
-	msgDescriptor := reflectMessage.Descriptor()
-
-	// Сначала собираем все непустые oneof поля.
-	oneOfs := msgDescriptor.Oneofs()
-	for i := 0; i < oneOfs.Len(); i++ {
-		oneof := oneOfs.Get(i)
-		field := reflectMessage.WhichOneof(oneof)
-		fields[pc.fieldKey(field)] = field
-	}
-	// Затем - все остальные поля.
-	commonFields := msgDescriptor.Fields()
-	for i := 0; i < commonFields.Len(); i++ {
-		field := commonFields.Get(i)
-		// Если это поле - одно из значений oneof'а - пропускаем его, т.к. оно
-		// уже добавлено в слайс.
-		if field.ContainingOneof() != nil {
+	msgFields := reflectMessage.Descriptor().Fields()
+	fields := make([]pref.FieldDescriptor, 0, msgFields.Len())
+
+	for i := 0; i < msgFields.Len(); i++ {
+		field := msgFields.Get(i)
+		// Если это поле - одно из значений oneof'а, то оно попадает в документ,
+		// только если именно оно выбрано в oneof'е. Синтетические oneof'ы proto3
+		// optional полей - это обычные поля с присутствием.
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() &&
+			reflectMessage.WhichOneof(oneof) != field {
 			continue
 		}
-		fields[pc.fieldKey(field)] = field
+		fields = append(fields, field)
+	}
+	// Дескриптор перечисляет поля в порядке объявления, для остальных режимов
+	// их нужно отсортировать.
+	if pc.registry.MarshalOptions.FieldOrder == NumberOrder {
+		sort.Slice(fields, func(i, j int) bool {
+			return fields[i].Number() < fields[j].Number()
+		})
 	}
 
 	return fields
 }
 
+// isNullValueField сообщает, является ли BSON null обычным значением поля
+// field, а не признаком незаполненного поля.
+func isNullValueField(field pref.FieldDescriptor) bool {
+	if field.IsList() || field.IsMap() {
+		return false
+	}
+	if field.Message() != nil {
+		return isNullValueMessage(field.Message())
+	}
+	return field.Enum() != nil && field.Enum().FullName() == nullValueEnumName
+}
+
+// isNullValueMessage сообщает, кодируется ли заполненное сообщение md как BSON
+// null. Для таких сообщений null не означает незаполненное поле.
+func isNullValueMessage(md pref.MessageDescriptor) bool {
+	switch md.FullName() {
+	case ProtobufKindValue, ProtobufKindEmpty:
+		return true
+	}
+	return false
+}
+
 func (pc *protobufMessageCodec) EncodeValue(
 	ctx bsoncodec.EncodeContext, w bsonrw.ValueWriter, val pref.Value,
 ) error {
-	msg := val.Message().Interface()
-	reflectMsg := msg.ProtoReflect()
-
 	dw, err := w.WriteDocument()
 	if err != nil {
 		return err
 	}
-	func() { _ = dw.WriteDocumentEnd() }()
+	if err = pc.encodeFields(ctx, dw, val.Message()); err != nil {
+		return err
+	}
+	return dw.WriteDocumentEnd()
+}
+
+// encodeFields пишет поля сообщения reflectMsg в документ dw. Поля встроенных
+// (inline) сообщений пишутся в тот же документ.
+func (pc *protobufMessageCodec) encodeFields(
+	ctx bsoncodec.EncodeContext, dw bsonrw.DocumentWriter, reflectMsg pref.Message,
+) error {
+	opts := pc.registry.MarshalOptions
+	// Документ с повторяющимися ключами не пишется: стратегия именования,
+	// которая дает двум полям один ключ, - ошибка.
+	if _, err := pc.registry.encodeFieldKeys(reflectMsg.Descriptor()); err != nil {
+		return err
+	}
 
-	for _, field := range pc.getMessageFields(msg) {
+	for _, field := range pc.getMessageFields(reflectMsg.Interface()) {
+		if getFieldOptions(field).GetOmit() {
+			continue
+		}
 		value := reflectMsg.Get(field)
 		if !value.IsValid() {
 			continue
 		}
-		writer, err := dw.WriteDocumentElement(pc.fieldKey(field))
+		inline, err := isInlineField(field)
 		if err != nil {
 			return err
 		}
-		codec, ok := pc.registry.GetCodecByField(field)
-		if ok {
-			err = codec.EncodeValue(ctx, writer, value)
-		} else {
-			err = pc.registry.BasicCodec.EncodeValue(ctx, writer, value)
+		if inline {
+			if err = pc.encodeFields(ctx, dw, value.Message()); err != nil {
+				return err
+			}
+			continue
+		}
+		// Незаполненные поля по умолчанию не пишутся, как в protojson. Поля с
+		// присутствием можно записать как null, остальные - нулевыми значениями.
+		// Поля, для которых null - заполненное значение, не пишутся никогда.
+		writeNull := false
+		if !reflectMsg.Has(field) {
+			switch {
+			case isNullValueField(field):
+				continue
+			case field.HasPresence() && (opts.EmitNullForUnset || opts.EmitUnpopulated):
+				writeNull = true
+			case !field.HasPresence() && opts.EmitUnpopulated:
+			default:
+				continue
+			}
+		}
+		key := fieldKey(opts.FieldNaming, field)
+		if realOneof(field) != nil && opts.OneofMode != OneofFlat {
+			if err = pc.encodeOneof(ctx, dw, opts.OneofMode, field, key, value); err != nil {
+				encodeErr := wrapEncodeError(oneofKey(field.ContainingOneof()), nil, err)
+				encodeErr.Message = reflectMsg.Descriptor().FullName()
+				return encodeErr
+			}
+			continue
 		}
+		writer, err := dw.WriteDocumentElement(key)
 		if err != nil {
 			return err
 		}
+		switch {
+		case writeNull:
+			err = writer.WriteNull()
+		case key == IDKey && isStringIDField(field):
+			err = encodeStringID(writer, value.String())
+		default:
+			err = pc.registry.encodeFieldValue(ctx, writer, field, value)
+		}
+		if err != nil {
+			encodeErr := wrapEncodeError(key, field, err)
+			encodeErr.Message = reflectMsg.Descriptor().FullName()
+			return encodeErr
+		}
 	}
-	return nil
-}
-
-func (pc *protobufMessageCodec) getAllMessageFields(msg proto.Message) map[string]pref.FieldDescriptor {
-	fields := make(map[string]pref.FieldDescriptor)
-	reflectMessage := msg.ProtoReflect()
-	msgDescriptor := reflectMessage.Descriptor()
-
-	commonFields := msgDescriptor.Fields()
-	for i := 0; i < commonFields.Len(); i++ {
-		field := commonFields.Get(i)
-		fields[pc.fieldKey(field)] = field
-	}
-
-	return fields
+	return pc.encodeUnknown(dw, reflectMsg)
 }
 
 func (pc *protobufMessageCodec) DecodeValue(
 	ctx bsoncodec.DecodeContext, r bsonrw.ValueReader, val pref.Value,
 ) error {
-	Logger.Debug("Start decoding into message document", zap.Field{Key: "main"})
-
+	logger := pc.registry.logger()
 	msg := val.Message().Interface()
 	reflectMsg := msg.ProtoReflect()
-	msgFieldsMap := pc.getAllMessageFields(msg)
-	msgName := string(reflectMsg.Descriptor().FullName())
+	msgFullName := reflectMsg.Descriptor().FullName()
+	msgName := string(msgFullName)
+	msgFieldsMap, err := pc.registry.decodeFieldKeys(reflectMsg.Descriptor())
+	if err != nil {
+		return err
+	}
 
-	Logger.Debug(
-		"Starting iteration over all msg fields",
-		zap.Field{Key: "MSG", Interface: msg},
-	)
+	logger.Debug("Start decoding into message document", "msg", msgName)
 	docReader, err := r.ReadDocument()
 	if err != nil {
 		return err
 	}
 
+	strict := pc.registry.UnmarshalOptions.Strict
+	seenKeys := make(map[string]bool)
+	decoded := make(decodedOneofs)
+	var errs error
 	for {
 		strKey, valueReader, err := docReader.ReadElement()
-		if err != bsonrw.ErrEOD && err != nil {
+		if err == bsonrw.ErrEOD {
+			logger.Debug("Stopping iteration cause of EOD err.", "msg", msgName)
 			break
+		} else if err != nil {
+			return multierr.Append(errs, err)
 		}
-		var field, ok = msgFieldsMap[strKey]
-		if !ok {
-			Logger.Debug(
-				"Can't find field for such bson key", zap.String("msg", msgName),
-				zap.String("key", strKey),
-			)
-			continue
+		if valueReader, err = pc.registry.detachValue(valueReader); err != nil {
+			return multierr.Append(errs, err)
+		}
+		if strict {
+			if seenKeys[strKey] {
+				err = &DecodeError{Message: msgFullName, Path: strKey, BSONType: valueReader.Type(), Err: fmt.Errorf("duplicate key")}
+				if !pc.registry.appendDecodeError(&errs, err) {
+					return errs
+				}
+				continue
+			}
+			seenKeys[strKey] = true
 		}
-		Logger.Debug("Stopping iteration cause of OEF err.", zap.String("Object key", strKey))
-
 		// Получение очередного поля документа.
-		field, ok = msgFieldsMap[strKey]
-		fieldName := string(field.FullName())
-		if !ok {
-			Logger.Warn("Can't get field by name.", zap.String("Msg", msgName), zap.String("fieldKey", strKey))
+		keyed, ok := msgFieldsMap[strKey]
+		// Вложенный документ oneof'а, записанный в режиме OneofWrapped или
+		// OneofDiscriminator. Декодирование принимает любой режим.
+		if ok && keyed.oneof != nil {
+			bsonType := valueReader.Type()
+			oneofMsg := mutableInline(reflectMsg, keyed.inlinePath)
+			if err = pc.decodeOneof(ctx, valueReader, oneofMsg, keyed.oneof, decoded); err != nil {
+				err = wrapDecodeErrors(msgFullName, strKey, nil, bsonType, err)
+				if !pc.registry.appendDecodeError(&errs, err) {
+					return errs
+				}
+			}
 			continue
 		}
-		// Поиск кодека и приведение значения.
-		codec, ok := pc.registry.GetCodecByField(field)
-		value := reflectMsg.NewField(field)
-		// Если кодек был найдет, то испоьзуется он, иначе - кодек для базовых типов.
-		if ok {
-			Logger.Debug("Found special codec for field ", zap.String("field", fieldName))
-			err = codec.DecodeValue(ctx, valueReader, value)
-			if err != nil {
-				Logger.Error("Can't save value into field.", zap.String("field", fieldName), zap.Any("valueReader", valueReader), zap.Error(err))
+		if !ok {
+			logger.Debug("Can't find field for such bson key", "msg", msgName, "key", strKey)
+			if strict {
+				err = &DecodeError{Message: msgFullName, Path: strKey, BSONType: valueReader.Type(), Err: fmt.Errorf("unknown key")}
+				if !pc.registry.appendDecodeError(&errs, err) {
+					return errs
+				}
 				continue
 			}
-		} else {
-			Logger.Info("Found basic codec for field ", zap.String("field", fieldName), zap.Any("value", value.Interface()), zap.Reflect("valType", reflect.TypeOf(value.Interface())))
-			basicValType := reflect.TypeOf(value.Interface())
-			basicVal, err := pc.registry.BasicCodec.DecodeValue(ctx, valueReader, basicValType)
-
-			if err != nil {
-				Logger.Error("Can't save value into field.", zap.String("field", fieldName), zap.Any("valueReader", valueReader), zap.Error(err))
+			if pc.registry.UnmarshalOptions.PreserveUnknown {
+				if err = preserveUnknown(reflectMsg, strKey, valueReader); err != nil {
+					return multierr.Append(errs, err)
+				}
 				continue
 			}
-			value = pref.ValueOf(basicVal)
+			// Значение нужно пропустить, иначе читатель не сможет перейти к
+			// следующему элементу документа.
+			if err = valueReader.Skip(); err != nil {
+				return multierr.Append(errs, err)
+			}
+			continue
+		}
+		field := keyed.field
+		fieldMsg := mutableInline(reflectMsg, keyed.inlinePath)
+		bsonType := valueReader.Type()
+		// В oneof'е может быть заполнено только одно поле. null очищает oneof,
+		// если выбрано именно это поле.
+		if oneof := realOneof(field); oneof != nil && bsonType == bsontype.Null {
+			if fieldMsg.WhichOneof(oneof) == field {
+				decoded.reset(fieldMsg, oneof)
+			}
+		} else if oneof != nil {
+			if err = decoded.set(fieldMsg, oneof, string(field.Name())); err != nil {
+				err = &DecodeError{Message: msgFullName, Path: strKey, BSONType: bsonType, Kind: field.Kind(), Err: err}
+				if !pc.registry.appendDecodeError(&errs, err) {
+					return errs
+				}
+				continue
+			}
+		}
+		if err = pc.decodeField(ctx, valueReader, fieldMsg, field, strKey); err != nil {
+			err = wrapDecodeErrors(msgFullName, strKey, field, bsonType, err)
+			if !pc.registry.appendDecodeError(&errs, err) {
+				return errs
+			}
+		}
+	}
+	return errs
+}
+
+// mutableInline возвращает встроенное сообщение, к которому ведет inlinePath от
+// msg. Поле встроенного сообщения записывается в это сообщение, которое
+// создается при необходимости.
+func mutableInline(msg pref.Message, inlinePath []pref.FieldDescriptor) pref.Message {
+	for _, inlineField := range inlinePath {
+		msg = msg.Mutable(inlineField).Message()
+	}
+	return msg
+}
+
+// decodeField декодирует значение по ключу key в поле field сообщения msg.
+func (pc *protobufMessageCodec) decodeField(
+	ctx bsoncodec.DecodeContext, r bsonrw.ValueReader, msg pref.Message,
+	field pref.FieldDescriptor, key string,
+) error {
+	// Строковый идентификатор мог быть сохранен как ObjectID.
+	if key == IDKey && isStringIDField(field) && r.Type() == bsontype.ObjectID {
+		id, err := decodeStringID(r)
+		if err != nil {
+			return err
+		}
+		msg.Set(field, pref.ValueOfString(id))
+		return nil
+	}
+	// BSON null означает, что поле не заполнено, и очищает его. Исключения -
+	// поля, для которых null - обычное значение, например,
+	// google.protobuf.Value.
+	if r.Type() == bsontype.Null && !isNullValueField(field) {
+		if err := r.ReadNull(); err != nil {
+			return err
+		}
+		msg.Clear(field)
+		return nil
+	}
+	// Поиск кодека и приведение значения.
+	value, err := pc.registry.decodeFieldValue(ctx, r, field, msg.NewField(field))
+	if err != nil {
+		// Когда ошибки собираются, вложенные сообщения, списки и мапы
+		// возвращают *DecodeError своих элементов, а все, что удалось
+		// декодировать, уже записано в value.
+		var decodeErr *DecodeError
+		if pc.registry.UnmarshalOptions.CollectErrors && value.IsValid() && errors.As(err, &decodeErr) {
+			msg.Set(field, value)
 		}
-		reflectMsg.Set(field, value)
+		return err
 	}
+	msg.Set(field, value)
 	return nil
 }
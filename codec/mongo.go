@@ -0,0 +1,38 @@
+package codec
+
+import (
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/protobuf/proto"
+)
+
+// protoMessageType - тип интерфейса proto.Message, на который вешаются хуки
+// кодека в реестре драйвера.
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// RegisterProtobufMongoCodec регистрирует codec в rb как хук для всех типов,
+// реализующих proto.Message. После этого коллекции драйвера кодируют и декодируют
+// сообщения через CodecsRegistry кодека без дополнительных оберток.
+func RegisterProtobufMongoCodec(
+	rb *bsoncodec.RegistryBuilder, codec *ProtobufMongoCodec,
+) *bsoncodec.RegistryBuilder {
+	return rb.
+		RegisterHookEncoder(protoMessageType, codec).
+		RegisterHookDecoder(protoMessageType, codec)
+}
+
+// NewBSONRegistry возвращает стандартный реестр драйвера, дополненный кодеком
+// codec для сообщений Protobuf'а.
+func NewBSONRegistry(codec *ProtobufMongoCodec) *bsoncodec.Registry {
+	return RegisterProtobufMongoCodec(bson.NewRegistryBuilder(), codec).Build()
+}
+
+// NewClientOptions возвращает настройки клиента, в которых уже выставлен реестр
+// из NewBSONRegistry. Их можно дополнить остальными настройками и передать в
+// mongo.Connect.
+func NewClientOptions(codec *ProtobufMongoCodec) *options.ClientOptions {
+	return options.Client().SetRegistry(NewBSONRegistry(codec))
+}
@@ -0,0 +1,60 @@
+package codec
+
+import (
+	"testing"
+	"time"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+var example = &gen.Example{
+	StringField:  "string",
+	EnumField:    gen.ExampleEnum_VAL_1,
+	ExampleOneof: &gen.Example_Int64Field{Int64Field: 64},
+	NestedMessage: &gen.NestedMessage{
+		NestedStringField: "nested",
+		NestedInt32Field:  32,
+	},
+	Projects: map[string]bool{"first": true, "second": false},
+	AnyField: &anypb.Any{TypeUrl: "type.example.com/unknown", Value: []byte{1, 2, 3}},
+	StrArray: []string{"a", "b", "c"},
+	// DateTime хранит миллисекунды, поэтому метка без более мелких долей.
+	Ts: timestamppb.New(time.Date(2021, 8, 23, 17, 34, 4, 118000000, time.UTC)),
+}
+
+func TestBSONRegistryRoundTrip(t *testing.T) {
+	assert := asrt.New(t)
+	registry := NewBSONRegistry(NewProtobufMongoCodec(Config{}))
+
+	bsonData, err := bson.MarshalWithRegistry(registry, example)
+	assert.Nil(err)
+
+	decoded := &gen.Example{}
+	err = bson.UnmarshalWithRegistry(registry, bsonData, decoded)
+	assert.Nil(err)
+	assert.True(proto.Equal(example, decoded), "decoded message must be equal to the original")
+}
+
+func TestBSONRegistryStructField(t *testing.T) {
+	assert := asrt.New(t)
+	registry := NewBSONRegistry(NewProtobufMongoCodec(Config{}))
+
+	type document struct {
+		Example *gen.Example `bson:"example"`
+		Missing *gen.Example `bson:"missing"`
+	}
+	bsonData, err := bson.MarshalWithRegistry(registry, document{Example: example})
+	assert.Nil(err)
+
+	var decoded document
+	err = bson.UnmarshalWithRegistry(registry, bsonData, &decoded)
+	assert.Nil(err)
+	assert.True(proto.Equal(example, decoded.Example), "decoded message must be equal to the original")
+	assert.Nil(decoded.Missing)
+}
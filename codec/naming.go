@@ -0,0 +1,227 @@
+package codec
+
+import (
+	"fmt"
+	"strconv"
+
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// NamingStrategy определяет ключ, под которым поле сообщения хранится в BSON
+// документе. Одна и та же стратегия должна использоваться и при кодировании, и
+// при декодировании, иначе поля не найдутся по своим ключам.
+type NamingStrategy interface {
+	FieldKey(field pref.FieldDescriptor) string
+}
+
+// NamingFunc позволяет использовать обычную функцию в качестве стратегии
+// именования, например, для имен в стиле полей Go структур.
+type NamingFunc func(field pref.FieldDescriptor) string
+
+func (f NamingFunc) FieldKey(field pref.FieldDescriptor) string {
+	return f(field)
+}
+
+var (
+	// ProtoNames - ключом служит имя поля из .proto файла (string_field).
+	// Используется по умолчанию.
+	ProtoNames NamingStrategy = protoNames{}
+	// JSONNames - ключом служит JSON имя поля (stringField), как в protojson.
+	JSONNames NamingStrategy = jsonNames{}
+	// FieldNumbers - ключом служит номер поля ("3").
+	FieldNumbers NamingStrategy = fieldNumbers{}
+)
+
+type protoNames struct{}
+
+func (protoNames) FieldKey(field pref.FieldDescriptor) string {
+	return string(field.Name())
+}
+
+type jsonNames struct{}
+
+func (jsonNames) FieldKey(field pref.FieldDescriptor) string {
+	return field.JSONName()
+}
+
+type fieldNumbers struct{}
+
+func (fieldNumbers) FieldKey(field pref.FieldDescriptor) string {
+	return strconv.Itoa(int(field.Number()))
+}
+
+// WithIDField возвращает стратегию, которая хранит поле с именем name под
+// ключом _id, а ключи остальных полей берет у naming. Так поле идентификатора
+// задается без разметки .proto файла опцией (proto_bson.field) = {id: true}.
+func WithIDField(name pref.Name, naming NamingStrategy) NamingStrategy {
+	return idFieldNaming{name: name, naming: namingOrDefault(naming)}
+}
+
+type idFieldNaming struct {
+	name   pref.Name
+	naming NamingStrategy
+}
+
+func (n idFieldNaming) FieldKey(field pref.FieldDescriptor) string {
+	if field.Name() == n.name {
+		return IDKey
+	}
+	return n.naming.FieldKey(field)
+}
+
+// namingOrDefault возвращает naming, либо стратегию по умолчанию, если она не
+// задана в настройках.
+func namingOrDefault(naming NamingStrategy) NamingStrategy {
+	if naming == nil {
+		return ProtoNames
+	}
+	return naming
+}
+
+// fieldKey возвращает ключ поля field в BSON документе. Опции поля из
+// proto_bson/options.proto имеют приоритет над стратегией naming.
+func fieldKey(naming NamingStrategy, field pref.FieldDescriptor) string {
+	opts := getFieldOptions(field)
+	switch {
+	case opts.GetId():
+		return IDKey
+	case opts.GetName() != "":
+		return opts.GetName()
+	}
+	return namingOrDefault(naming).FieldKey(field)
+}
+
+// keyedField - поле или oneof, найденные по ключу BSON документа. Заполнено
+// либо field, либо oneof - ключ вложенного документа oneof'а в режимах
+// OneofWrapped и OneofDiscriminator. Если поле принадлежит встроенному (inline)
+// сообщению, то inlinePath содержит цепочку полей, ведущую к нему от корневого
+// сообщения.
+type keyedField struct {
+	field      pref.FieldDescriptor
+	oneof      pref.OneofDescriptor
+	inlinePath []pref.FieldDescriptor
+}
+
+// fullName возвращает полное имя поля или oneof'а.
+func (k keyedField) fullName() pref.FullName {
+	if k.oneof != nil {
+		return k.oneof.FullName()
+	}
+	return k.field.FullName()
+}
+
+// messageFieldKeys возвращает поля и oneof'ы сообщения md по их BSON ключам,
+// включая поля встроенных сообщений. Поля с опцией omit в результат не
+// попадают. Если двум полям или полю и oneof'у соответствует один и тот же
+// ключ, возвращается ошибка.
+func messageFieldKeys(
+	naming NamingStrategy, md pref.MessageDescriptor,
+) (map[string]keyedField, error) {
+	keys := make(map[string]keyedField, md.Fields().Len())
+	if err := collectFieldKeys(naming, md, nil, keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func collectFieldKeys(
+	naming NamingStrategy, md pref.MessageDescriptor,
+	inlinePath []pref.FieldDescriptor, keys map[string]keyedField,
+) error {
+	oneofs := md.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		oneof := oneofs.Get(i)
+		if oneof.IsSynthetic() {
+			continue
+		}
+		if err := addFieldKey(keys, oneofKey(oneof), keyedField{oneof: oneof, inlinePath: inlinePath}); err != nil {
+			return err
+		}
+	}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if getFieldOptions(field).GetOmit() {
+			continue
+		}
+		inline, err := isInlineField(field)
+		if err != nil {
+			return err
+		}
+		if inline {
+			// Копия нужна, чтобы соседние встроенные поля не делили один массив.
+			path := append(append([]pref.FieldDescriptor(nil), inlinePath...), field)
+			if len(path) > maxInlineDepth {
+				return fmt.Errorf("field %s: inlining is too deep, recursive message?", field.FullName())
+			}
+			if err = collectFieldKeys(naming, field.Message(), path, keys); err != nil {
+				return err
+			}
+			continue
+		}
+		key := fieldKey(naming, field)
+		if err = addFieldKey(keys, key, keyedField{field: field, inlinePath: inlinePath}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addFieldKey добавляет keyed в keys под ключом key. Если ключ уже занят,
+// возвращается ошибка.
+func addFieldKey(keys map[string]keyedField, key string, keyed keyedField) error {
+	if other, ok := keys[key]; ok {
+		return fmt.Errorf(
+			"%s and %s have the same BSON key %q", other.fullName(), keyed.fullName(), key,
+		)
+	}
+	keys[key] = keyed
+	return nil
+}
+
+// maxInlineDepth ограничивает вложенность inline полей, чтобы рекурсивные
+// сообщения не приводили к бесконечному обходу.
+const maxInlineDepth = 32
+
+// ValidateFieldKeys проверяет, что стратегия naming дает всем полям сообщений
+// messages и вложенных в них сообщений разные ключи, и что в них не объявлен
+// номер поля, зарезервированный для PreserveUnknown. Ее стоит вызывать при
+// старте приложения для всех хранимых в Mongo типов, чтобы конфликт ключей
+// обнаружился до первой записи.
+func ValidateFieldKeys(naming NamingStrategy, messages ...pref.MessageDescriptor) error {
+	visited := make(map[pref.FullName]bool)
+	var validate func(md pref.MessageDescriptor) error
+	validate = func(md pref.MessageDescriptor) error {
+		if visited[md.FullName()] {
+			return nil
+		}
+		visited[md.FullName()] = true
+		if _, err := messageFieldKeys(naming, md); err != nil {
+			return err
+		}
+		if err := checkUnknownFieldNumber(md); err != nil {
+			return err
+		}
+		fields := md.Fields()
+		for i := 0; i < fields.Len(); i++ {
+			field := fields.Get(i)
+			if field.IsMap() {
+				field = field.MapValue()
+			}
+			if field.Message() == nil {
+				continue
+			}
+			if err := validate(field.Message()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, md := range messages {
+		if err := validate(md); err != nil {
+			return err
+		}
+	}
+	return nil
+}
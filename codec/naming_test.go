@@ -0,0 +1,73 @@
+package codec
+
+import (
+	"strings"
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestFieldNaming(t *testing.T) {
+	goNames := NamingFunc(func(field pref.FieldDescriptor) string {
+		return strings.ToLower(strings.ReplaceAll(string(field.Name()), "_", ""))
+	})
+	cases := []struct {
+		naming NamingStrategy
+		key    string
+	}{
+		{naming: nil, key: "string_field"},
+		{naming: ProtoNames, key: "string_field"},
+		{naming: JSONNames, key: "stringField"},
+		{naming: FieldNumbers, key: "3"},
+		{naming: goNames, key: "stringfield"},
+	}
+	for _, c := range cases {
+		assert := asrt.New(t)
+
+		bsonData, err := MarshalOptions{FieldNaming: c.naming}.Marshal(example)
+		assert.Nil(err)
+		value, err := bson.Raw(bsonData).LookupErr(c.key)
+		assert.Nil(err, "key %q must be present", c.key)
+		assert.Equal(example.StringField, value.StringValue())
+
+		decoded := &gen.Example{}
+		err = UnmarshalOptions{FieldNaming: c.naming}.Unmarshal(bsonData, decoded)
+		assert.Nil(err)
+		assert.True(proto.Equal(example, decoded), "decoded message must be equal to the original")
+	}
+}
+
+func TestValidateFieldKeys(t *testing.T) {
+	assert := asrt.New(t)
+	md := example.ProtoReflect().Descriptor()
+
+	assert.Nil(ValidateFieldKeys(JSONNames, md))
+
+	// Ключи полей вложенного сообщения совпадают.
+	nestedPrefix := NamingFunc(func(field pref.FieldDescriptor) string {
+		if field.Parent().Name() == "NestedMessage" {
+			return "nested"
+		}
+		return string(field.Name())
+	})
+	err := ValidateFieldKeys(nestedPrefix, md)
+	assert.NotNil(err)
+
+	bsonData, err := Marshal(example)
+	assert.Nil(err)
+	err = UnmarshalOptions{FieldNaming: nestedPrefix}.Unmarshal(bsonData, &gen.Example{})
+	assert.NotNil(err)
+}
+
+func TestEncodeFieldKeyCollision(t *testing.T) {
+	assert := asrt.New(t)
+	sameKey := NamingFunc(func(pref.FieldDescriptor) string { return "x" })
+
+	_, err := MarshalOptions{FieldNaming: sameKey}.Marshal(&gen.Scalars{Int32Field: 1, Int64Field: 2})
+	assert.NotNil(err, "document with duplicate keys must not be written")
+}
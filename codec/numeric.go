@@ -0,0 +1,303 @@
+package codec
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// NumericCoercion определяет, какие BSON типы принимаются при декодировании
+// числовых и логических полей, если тип в документе не совпадает с видом поля.
+//
+// Матрица приведений (L - только в NumericLenient):
+//
+//	вид поля                   Int32  Int64  Double  Decimal128  String  Boolean
+//	int32, sint32, sfixed32    да     да     да      да          L       -
+//	int64, sint64, sfixed64    да     да     да      да          L       -
+//	uint32, fixed32            да     да     да      да          L       -
+//	uint64, fixed64            да     да(1)  да      да          да(2)   -
+//	float                      да     да     да      L           L       -
+//	double                     да     да     да      L           L       -
+//	bool                       L(3)   L(3)   -       -           -       да
+//
+// (1) int64 считается представлением Uint64Int64, т.е. биты сохраняются.
+// (2) Десятичная строка - представление Uint64String.
+// (3) Только 0 и 1.
+//
+// Переполнение - всегда ошибка. В режиме NumericLossless ошибка - и любая
+// потеря точности: дробная часть у целого поля, double, который не
+// представим во float точно, int64 за пределами точности double. В режиме
+// NumericLenient дробная часть отбрасывается, а числа с плавающей запятой
+// округляются.
+type NumericCoercion int
+
+const (
+	// NumericLossless принимает только приведения без потерь. Используется по
+	// умолчанию.
+	NumericLossless NumericCoercion = iota
+	// NumericLenient дополнительно принимает числа в строках и приведения с
+	// округлением.
+	NumericLenient
+)
+
+var (
+	minInt32  = big.NewInt(math.MinInt32)
+	maxInt32  = big.NewInt(math.MaxInt32)
+	minInt64  = big.NewInt(math.MinInt64)
+	maxInt64  = big.NewInt(math.MaxInt64)
+	maxUint32 = big.NewInt(math.MaxUint32)
+	maxUint64 = new(big.Int).SetUint64(math.MaxUint64)
+	bigZero   = big.NewInt(0)
+)
+
+// isNumericKind сообщает, декодируется ли поле вида kind по матрице
+// NumericCoercion.
+func isNumericKind(kind pref.Kind) bool {
+	switch kind {
+	case pref.Int32Kind, pref.Sint32Kind, pref.Sfixed32Kind,
+		pref.Int64Kind, pref.Sint64Kind, pref.Sfixed64Kind,
+		pref.Uint32Kind, pref.Fixed32Kind, pref.Uint64Kind, pref.Fixed64Kind,
+		pref.FloatKind, pref.DoubleKind, pref.BoolKind:
+		return true
+	}
+	return false
+}
+
+// decodeNumeric читает значение числового или логического поля fd по
+// матрице NumericCoercion.
+func (pc *protobufBasicCodec) decodeNumeric(
+	r bsonrw.ValueReader, fd pref.FieldDescriptor,
+) (pref.Value, error) {
+	lenient := pc.registry.UnmarshalOptions.NumericCoercion == NumericLenient
+	return decodeNumericKind(r, fd.Kind(), lenient)
+}
+
+func decodeNumericKind(r bsonrw.ValueReader, kind pref.Kind, lenient bool) (pref.Value, error) {
+	switch kind {
+	case pref.Int32Kind, pref.Sint32Kind, pref.Sfixed32Kind:
+		i, err := readBigInt(r, lenient, minInt32, maxInt32)
+		if err != nil {
+			return pref.Value{}, err
+		}
+		return pref.ValueOfInt32(int32(i.Int64())), nil
+	case pref.Int64Kind, pref.Sint64Kind, pref.Sfixed64Kind:
+		i, err := readBigInt(r, lenient, minInt64, maxInt64)
+		if err != nil {
+			return pref.Value{}, err
+		}
+		return pref.ValueOfInt64(i.Int64()), nil
+	case pref.Uint32Kind, pref.Fixed32Kind:
+		i, err := readBigInt(r, lenient, bigZero, maxUint32)
+		if err != nil {
+			return pref.Value{}, err
+		}
+		return pref.ValueOfUint32(uint32(i.Uint64())), nil
+	case pref.Uint64Kind, pref.Fixed64Kind:
+		if r.Type() != bsontype.Double {
+			u, err := decodeUint64(r)
+			return pref.ValueOfUint64(u), err
+		}
+		i, err := readBigInt(r, lenient, bigZero, maxUint64)
+		if err != nil {
+			return pref.Value{}, err
+		}
+		return pref.ValueOfUint64(i.Uint64()), nil
+	case pref.FloatKind:
+		f, err := readFloat(r, lenient, 32)
+		return pref.ValueOfFloat32(float32(f)), err
+	case pref.DoubleKind:
+		f, err := readFloat(r, lenient, 64)
+		return pref.ValueOfFloat64(f), err
+	case pref.BoolKind:
+		b, err := readBool(r, lenient)
+		return pref.ValueOfBool(b), err
+	}
+	return pref.Value{}, fmt.Errorf("%s is not a numeric kind", kind)
+}
+
+// readBigInt читает целое число и проверяет, что оно лежит в [min, max].
+func readBigInt(r bsonrw.ValueReader, lenient bool, min, max *big.Int) (*big.Int, error) {
+	var i *big.Int
+	switch r.Type() {
+	case bsontype.Int32:
+		v, err := r.ReadInt32()
+		if err != nil {
+			return nil, err
+		}
+		i = big.NewInt(int64(v))
+	case bsontype.Int64:
+		v, err := r.ReadInt64()
+		if err != nil {
+			return nil, err
+		}
+		i = big.NewInt(v)
+	case bsontype.Double:
+		v, err := r.ReadDouble()
+		if err != nil {
+			return nil, err
+		}
+		if i, err = floatToBigInt(v, lenient); err != nil {
+			return nil, err
+		}
+	case bsontype.Decimal128:
+		v, err := r.ReadDecimal128()
+		if err != nil {
+			return nil, err
+		}
+		if i, err = decimal128ToBigInt(v, lenient); err != nil {
+			return nil, err
+		}
+	case bsontype.String:
+		if !lenient {
+			return nil, fmt.Errorf("string is accepted in lenient mode only")
+		}
+		v, err := r.ReadString()
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if i, ok = new(big.Int).SetString(v, 10); !ok {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("string %q is not a number", v)
+			}
+			if i, err = floatToBigInt(f, lenient); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("can't decode integer from BSON %s", r.Type())
+	}
+	if i.Cmp(min) < 0 || i.Cmp(max) > 0 {
+		return nil, fmt.Errorf("%s overflows range [%s, %s]", i, min, max)
+	}
+	return i, nil
+}
+
+// floatToBigInt переводит f в целое. Дробная часть допустима только в
+// нестрогом режиме и отбрасывается.
+func floatToBigInt(f float64, lenient bool) (*big.Int, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("double %v is not an integer", f)
+	}
+	if f != math.Trunc(f) && !lenient {
+		return nil, fmt.Errorf("double %v has a fractional part", f)
+	}
+	i, _ := big.NewFloat(math.Trunc(f)).Int(nil)
+	return i, nil
+}
+
+// decimal128ToBigInt переводит d в целое. Дробная часть допустима только в
+// нестрогом режиме и отбрасывается.
+func decimal128ToBigInt(d primitive.Decimal128, lenient bool) (*big.Int, error) {
+	coef, exp, err := d.BigInt()
+	if err != nil {
+		return nil, fmt.Errorf("decimal128 %s is not an integer: %w", d, err)
+	}
+	ten := big.NewInt(10)
+	// Больше 40 десятичных разрядов не поместится ни в один целый вид, дальше
+	// умножать незачем.
+	for ; exp > 0 && coef.Sign() != 0 && coef.BitLen() <= 128; exp-- {
+		coef.Mul(coef, ten)
+	}
+	for ; exp < 0 && coef.Sign() != 0; exp++ {
+		var mod big.Int
+		coef.QuoRem(coef, ten, &mod)
+		if mod.Sign() != 0 && !lenient {
+			return nil, fmt.Errorf("decimal128 %s has a fractional part", d)
+		}
+	}
+	return coef, nil
+}
+
+// readFloat читает число с плавающей запятой разрядности bitSize (32 или 64).
+func readFloat(r bsonrw.ValueReader, lenient bool, bitSize int) (float64, error) {
+	switch r.Type() {
+	case bsontype.Double:
+		f, err := r.ReadDouble()
+		if err != nil {
+			return 0, err
+		}
+		if bitSize == 32 && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			if math.Abs(f) > math.MaxFloat32 {
+				return 0, fmt.Errorf("double %v overflows float", f)
+			}
+			if float64(float32(f)) != f && !lenient {
+				return 0, fmt.Errorf("double %v can't be represented as float exactly", f)
+			}
+		}
+		return f, nil
+	case bsontype.Int32, bsontype.Int64:
+		var i int64
+		if r.Type() == bsontype.Int32 {
+			v, err := r.ReadInt32()
+			if err != nil {
+				return 0, err
+			}
+			i = int64(v)
+		} else {
+			v, err := r.ReadInt64()
+			if err != nil {
+				return 0, err
+			}
+			i = v
+		}
+		prec := uint(53)
+		if bitSize == 32 {
+			prec = 24
+		}
+		f := new(big.Float).SetPrec(prec).SetInt64(i)
+		if f.Acc() != big.Exact && !lenient {
+			return 0, fmt.Errorf("integer %d can't be represented as %d-bit float exactly", i, bitSize)
+		}
+		v, _ := f.Float64()
+		return v, nil
+	case bsontype.Decimal128, bsontype.String:
+		if !lenient {
+			return 0, fmt.Errorf("%s is accepted in lenient mode only", r.Type())
+		}
+		var str string
+		if r.Type() == bsontype.Decimal128 {
+			d, err := r.ReadDecimal128()
+			if err != nil {
+				return 0, err
+			}
+			str = d.String()
+		} else {
+			s, err := r.ReadString()
+			if err != nil {
+				return 0, err
+			}
+			str = s
+		}
+		f, err := strconv.ParseFloat(str, bitSize)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number: %w", str, err)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("can't decode float from BSON %s", r.Type())
+}
+
+func readBool(r bsonrw.ValueReader, lenient bool) (bool, error) {
+	switch r.Type() {
+	case bsontype.Boolean:
+		return r.ReadBoolean()
+	case bsontype.Int32, bsontype.Int64:
+		if !lenient {
+			return false, fmt.Errorf("%s is accepted in lenient mode only", r.Type())
+		}
+		i, err := readBigInt(r, lenient, bigZero, big.NewInt(1))
+		if err != nil {
+			return false, err
+		}
+		return i.Sign() != 0, nil
+	}
+	return false, fmt.Errorf("can't decode bool from BSON %s", r.Type())
+}
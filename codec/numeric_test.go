@@ -0,0 +1,94 @@
+package codec
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestNumericCoercion(t *testing.T) {
+	assert := asrt.New(t)
+	decimal := func(s string) primitive.Decimal128 {
+		d, err := primitive.ParseDecimal128(s)
+		assert.Nil(err)
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		kind     pref.Kind
+		value    interface{}
+		expected interface{}
+		// lossless - приведение допустимо и в строгом режиме, lenient - только
+		// в нестрогом. Если оба false, это ошибка в любом режиме.
+		lossless, lenient bool
+	}{
+		{"int32 from int64", pref.Int32Kind, int64(7), int32(7), true, true},
+		{"int32 from double", pref.Int32Kind, 7.0, int32(7), true, true},
+		{"int32 from decimal", pref.Int32Kind, decimal("7.00"), int32(7), true, true},
+		{"int32 from fraction", pref.Int32Kind, 7.5, int32(7), false, true},
+		{"int32 from string", pref.Int32Kind, "7", int32(7), false, true},
+		{"int32 overflow", pref.Int32Kind, int64(math.MaxInt32 + 1), nil, false, false},
+		{"int32 from NaN", pref.Int32Kind, math.NaN(), nil, false, false},
+		{"int64 from double", pref.Int64Kind, 1e15, int64(1e15), true, true},
+		{"int64 overflow", pref.Int64Kind, 1e19, nil, false, false},
+		{"uint32 negative", pref.Uint32Kind, int32(-1), nil, false, false},
+		{"uint64 from double", pref.Uint64Kind, 7.0, uint64(7), true, true},
+		{"float from double", pref.FloatKind, 0.5, float32(0.5), true, true},
+		{"float rounding", pref.FloatKind, 0.1, float32(0.1), false, true},
+		{"float overflow", pref.FloatKind, math.MaxFloat64, nil, false, false},
+		{"double from int64", pref.DoubleKind, int64(1 << 53), float64(1 << 53), true, true},
+		{"double rounding", pref.DoubleKind, int64(1<<53 + 1), float64(1 << 53), false, true},
+		{"double from decimal", pref.DoubleKind, decimal("0.25"), 0.25, false, true},
+		{"bool from int32", pref.BoolKind, int32(1), true, false, true},
+		{"bool from 2", pref.BoolKind, int32(2), nil, false, false},
+		{"bool from string", pref.BoolKind, "true", nil, false, false},
+	}
+	for _, test := range tests {
+		for _, lenient := range []bool{false, true} {
+			value, err := decodeNumericKind(bsonValueReader(t, test.value), test.kind, lenient)
+			if accepted := test.lossless || lenient && test.lenient; !accepted {
+				assert.NotNil(err, "%s (lenient: %v)", test.name, lenient)
+				continue
+			}
+			if assert.Nil(err, "%s (lenient: %v)", test.name, lenient) {
+				assert.Equal(test.expected, value.Interface(), test.name)
+			}
+		}
+	}
+}
+
+func TestNumericCoercionField(t *testing.T) {
+	assert := asrt.New(t)
+	bsonData, err := bson.Marshal(bson.D{
+		{Key: "int32_field", Value: 7.0},
+		{Key: "int64_field", Value: "8"},
+		{Key: "double_field", Value: int32(9)},
+	})
+	assert.Nil(err)
+
+	err = Unmarshal(bsonData, &gen.Scalars{})
+	var decodeErr *DecodeError
+	if assert.True(errors.As(err, &decodeErr), "string must be rejected in lossless mode") {
+		assert.Equal("int64_field", decodeErr.Path)
+		assert.Equal(pref.Int64Kind, decodeErr.Kind)
+	}
+
+	decoded := &gen.Scalars{}
+	assert.Nil(UnmarshalOptions{NumericCoercion: NumericLenient}.Unmarshal(bsonData, decoded))
+	assert.Equal(int32(7), decoded.Int32Field)
+	assert.Equal(int64(8), decoded.Int64Field)
+	assert.Equal(9.0, decoded.DoubleField)
+
+	r := DefaultCodecsRegistry()
+	fd := decoded.ProtoReflect().Descriptor().Fields().ByName("int32_field")
+	_, err = r.BasicCodec.decodeNumeric(bsonValueReader(t, 7.5), fd)
+	assert.EqualError(err, "double 7.5 has a fractional part")
+}
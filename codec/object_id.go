@@ -0,0 +1,35 @@
+package codec
+
+import (
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// isStringIDField сообщает, является ли field одиночным строковым полем, значение
+// которого можно хранить в _id как ObjectID. Поля, размеченные как UUID,
+// хранятся как UUID.
+func isStringIDField(field pref.FieldDescriptor) bool {
+	return field.Kind() == pref.StringKind && field.Cardinality() != pref.Repeated &&
+		!getFieldOptions(field).GetUuid()
+}
+
+// encodeStringID пишет строковый идентификатор id. Если это 24 символа hex в
+// каноническом (нижнем) регистре, то он сохраняется как ObjectID, иначе - как
+// обычная строка. Проверка регистра нужна, чтобы декодирование вернуло ровно ту
+// же строку.
+func encodeStringID(w bsonrw.ValueWriter, id string) error {
+	if oid, err := primitive.ObjectIDFromHex(id); err == nil && oid.Hex() == id {
+		return w.WriteObjectID(oid)
+	}
+	return w.WriteString(id)
+}
+
+// decodeStringID читает ObjectID и возвращает его hex представление.
+func decodeStringID(r bsonrw.ValueReader) (string, error) {
+	oid, err := r.ReadObjectID()
+	if err != nil {
+		return "", err
+	}
+	return oid.Hex(), nil
+}
@@ -0,0 +1,209 @@
+package codec
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// OneofMode задает, как выбранное поле oneof'а хранится в документе.
+// Синтетические oneof'ы proto3 optional полей - это обычные поля, режим на них
+// не влияет.
+type OneofMode int
+
+const (
+	// OneofFlat - выбранное поле пишется в документ сообщения под своим ключом,
+	// как обычное поле. Используется по умолчанию.
+	OneofFlat OneofMode = iota
+	// OneofWrapped - выбранное поле пишется во вложенный документ под именем
+	// oneof'а: {oneof_name: {member: value}}.
+	OneofWrapped
+	// OneofDiscriminator - имя выбранного поля и его значение пишутся во
+	// вложенный документ под именем oneof'а: {oneof_name: {"_case": "member",
+	// "value": value}}. По ключу _case удобно строить запросы и индексы.
+	OneofDiscriminator
+)
+
+const (
+	// oneofCaseKey - ключ имени выбранного поля в режиме OneofDiscriminator.
+	oneofCaseKey = "_case"
+	// oneofValueKey - ключ значения выбранного поля в режиме
+	// OneofDiscriminator.
+	oneofValueKey = "value"
+)
+
+// realOneof возвращает oneof, которому принадлежит field, если он не
+// синтетический, иначе - nil.
+func realOneof(field pref.FieldDescriptor) pref.OneofDescriptor {
+	oneof := field.ContainingOneof()
+	if oneof == nil || oneof.IsSynthetic() {
+		return nil
+	}
+	return oneof
+}
+
+// oneofKey возвращает ключ вложенного документа oneof'а - его имя из .proto
+// файла. Стратегии именования задают ключи только полей.
+func oneofKey(oneof pref.OneofDescriptor) string {
+	return string(oneof.Name())
+}
+
+// decodedOneofs запоминает, каким ключом было заполнено каждое поле oneof,
+// чтобы найти документы, в которых выбрано несколько его полей.
+type decodedOneofs map[oneofInMessage]string
+
+type oneofInMessage struct {
+	msg   pref.Message
+	oneof pref.OneofDescriptor
+}
+
+// set отмечает, что oneof сообщения msg заполнен значением по ключу key. Если он
+// уже был заполнен, то возвращается ошибка.
+func (d decodedOneofs) set(msg pref.Message, oneof pref.OneofDescriptor, key string) error {
+	k := oneofInMessage{msg: msg, oneof: oneof}
+	if prev, ok := d[k]; ok {
+		return fmt.Errorf("oneof %s is already set by %q", oneof.Name(), prev)
+	}
+	d[k] = key
+	return nil
+}
+
+// reset отмечает, что oneof сообщения msg очищен, и его снова можно заполнить.
+func (d decodedOneofs) reset(msg pref.Message, oneof pref.OneofDescriptor) {
+	delete(d, oneofInMessage{msg: msg, oneof: oneof})
+}
+
+// encodeOneof пишет выбранное поле field oneof'а в документ dw в режиме mode.
+// key - ключ поля.
+func (pc *protobufMessageCodec) encodeOneof(
+	ctx bsoncodec.EncodeContext, dw bsonrw.DocumentWriter, mode OneofMode,
+	field pref.FieldDescriptor, key string, value pref.Value,
+) error {
+	writer, err := dw.WriteDocumentElement(oneofKey(field.ContainingOneof()))
+	if err != nil {
+		return err
+	}
+	oneofWriter, err := writer.WriteDocument()
+	if err != nil {
+		return err
+	}
+	valueKey := key
+	if mode == OneofDiscriminator {
+		caseWriter, err := oneofWriter.WriteDocumentElement(oneofCaseKey)
+		if err != nil {
+			return err
+		}
+		if err = caseWriter.WriteString(key); err != nil {
+			return err
+		}
+		valueKey = oneofValueKey
+	}
+	valueWriter, err := oneofWriter.WriteDocumentElement(valueKey)
+	if err != nil {
+		return err
+	}
+	if err = pc.registry.encodeFieldValue(ctx, valueWriter, field, value); err != nil {
+		return wrapEncodeError(valueKey, field, err)
+	}
+	return oneofWriter.WriteDocumentEnd()
+}
+
+// decodeOneof читает вложенный документ oneof'а в сообщение msg. Принимаются
+// оба режима: OneofWrapped и OneofDiscriminator. BSON null очищает oneof.
+func (pc *protobufMessageCodec) decodeOneof(
+	ctx bsoncodec.DecodeContext, r bsonrw.ValueReader, msg pref.Message,
+	oneof pref.OneofDescriptor, decoded decodedOneofs,
+) error {
+	if r.Type() == bsontype.Null {
+		if field := msg.WhichOneof(oneof); field != nil {
+			msg.Clear(field)
+		}
+		decoded.reset(msg, oneof)
+		return r.ReadNull()
+	}
+	members := make(map[string]pref.FieldDescriptor, oneof.Fields().Len())
+	for i := 0; i < oneof.Fields().Len(); i++ {
+		field := oneof.Fields().Get(i)
+		members[fieldKey(pc.registry.UnmarshalOptions.FieldNaming, field)] = field
+	}
+	docReader, err := r.ReadDocument()
+	if err != nil {
+		return err
+	}
+
+	var caseField pref.FieldDescriptor
+	// Значение, которое встретилось раньше ключа _case.
+	var pendingType bsontype.Type
+	var pendingValue []byte
+	for {
+		key, valueReader, err := docReader.ReadElement()
+		if err == bsonrw.ErrEOD {
+			break
+		} else if err != nil {
+			return err
+		}
+		switch {
+		case key == oneofCaseKey:
+			caseKey, err := valueReader.ReadString()
+			if err != nil {
+				return wrapDecodeError(key, nil, valueReader.Type(), err)
+			}
+			field, ok := members[caseKey]
+			if !ok {
+				return wrapDecodeError(key, nil, bsontype.String, fmt.Errorf("unknown oneof member %q", caseKey))
+			}
+			caseField = field
+			if pendingValue != nil {
+				pendingReader := bsonrw.NewBSONValueReader(pendingType, pendingValue)
+				if err = pc.decodeOneofMember(ctx, pendingReader, msg, field, oneofValueKey, decoded); err != nil {
+					return err
+				}
+			}
+		case key == oneofValueKey && members[key] == nil:
+			if caseField == nil {
+				pendingType, pendingValue, err = bsonrw.Copier{}.CopyValueToBytes(valueReader)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if err = pc.decodeOneofMember(ctx, valueReader, msg, caseField, key, decoded); err != nil {
+				return err
+			}
+		case members[key] != nil:
+			if err = pc.decodeOneofMember(ctx, valueReader, msg, members[key], key, decoded); err != nil {
+				return err
+			}
+		default:
+			if pc.registry.UnmarshalOptions.Strict {
+				return &DecodeError{Path: key, BSONType: valueReader.Type(), Err: fmt.Errorf("unknown key")}
+			}
+			if err = valueReader.Skip(); err != nil {
+				return err
+			}
+		}
+	}
+	if pendingValue != nil && caseField == nil {
+		return &DecodeError{Path: oneofValueKey, BSONType: pendingType, Err: fmt.Errorf("%s is missing", oneofCaseKey)}
+	}
+	return nil
+}
+
+// decodeOneofMember декодирует значение по ключу key в поле field oneof'а,
+// проверяя, что другие его поля еще не заполнены.
+func (pc *protobufMessageCodec) decodeOneofMember(
+	ctx bsoncodec.DecodeContext, r bsonrw.ValueReader, msg pref.Message,
+	field pref.FieldDescriptor, key string, decoded decodedOneofs,
+) error {
+	bsonType := r.Type()
+	if err := decoded.set(msg, field.ContainingOneof(), string(field.Name())); err != nil {
+		return &DecodeError{Path: key, BSONType: bsonType, Err: err}
+	}
+	if err := pc.decodeField(ctx, r, msg, field, key); err != nil {
+		return wrapDecodeError(key, field, bsonType, err)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package codec
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/protobuf/proto"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestOneofModes(t *testing.T) {
+	msg := &gen.Example{
+		StringField:  "a",
+		ExampleOneof: &gen.Example_OneStringField{OneStringField: "one"},
+	}
+	cases := []struct {
+		mode     OneofMode
+		key      string
+		expected interface{}
+	}{
+		{mode: OneofFlat, key: "one_string_field", expected: "one"},
+		{mode: OneofWrapped, key: "example_oneof.one_string_field", expected: "one"},
+		{mode: OneofDiscriminator, key: "example_oneof._case", expected: "one_string_field"},
+		{mode: OneofDiscriminator, key: "example_oneof.value", expected: "one"},
+	}
+	for _, c := range cases {
+		assert := asrt.New(t)
+
+		bsonData, err := MarshalOptions{OneofMode: c.mode}.Marshal(msg)
+		assert.Nil(err)
+		value, err := bson.Raw(bsonData).LookupErr(strings.Split(c.key, ".")...)
+		if assert.Nil(err, c.key) {
+			assert.Equal(c.expected, value.StringValue())
+		}
+
+		decoded := &gen.Example{}
+		assert.Nil(Unmarshal(bsonData, decoded))
+		assert.True(proto.Equal(msg, decoded), "decoded message must be equal to the original")
+	}
+}
+
+func TestOneofDecoding(t *testing.T) {
+	assert := asrt.New(t)
+
+	// Значение может идти раньше _case.
+	bsonData, err := bson.Marshal(bson.D{{Key: "example_oneof", Value: bson.D{
+		{Key: "value", Value: int32(7)},
+		{Key: "_case", Value: "int32_field"},
+	}}})
+	assert.Nil(err)
+	decoded := &gen.Example{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.Equal(int32(7), decoded.GetInt32Field())
+
+	// null очищает oneof.
+	bsonData, err = bson.Marshal(bson.D{
+		{Key: "int32_field", Value: int32(7)},
+		{Key: "example_oneof", Value: nil},
+	})
+	assert.Nil(err)
+	decoded = &gen.Example{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.Nil(decoded.ExampleOneof)
+}
+
+func TestOneofConflicts(t *testing.T) {
+	assert := asrt.New(t)
+	tests := map[string]bson.D{
+		"int64_field": {
+			{Key: "int32_field", Value: int32(1)},
+			{Key: "int64_field", Value: int64(2)},
+		},
+		"example_oneof.int64_field": {{Key: "example_oneof", Value: bson.D{
+			{Key: "int32_field", Value: int32(1)},
+			{Key: "int64_field", Value: int64(2)},
+		}}},
+		"example_oneof.one_string_field": {
+			{Key: "int32_field", Value: int32(1)},
+			{Key: "example_oneof", Value: bson.D{{Key: "one_string_field", Value: "one"}}},
+		},
+		"example_oneof._case": {{Key: "example_oneof", Value: bson.D{
+			{Key: "_case", Value: "unknown"},
+			{Key: "value", Value: 1},
+		}}},
+	}
+	for path, doc := range tests {
+		bsonData, err := bson.Marshal(doc)
+		assert.Nil(err)
+
+		err = Unmarshal(bsonData, &gen.Example{})
+		var decodeErr *DecodeError
+		if assert.True(errors.As(err, &decodeErr), path) {
+			assert.Equal(path, decodeErr.Path)
+		}
+	}
+}
+
+func TestOneofKeyCollision(t *testing.T) {
+	assert := asrt.New(t)
+	naming := NamingFunc(func(field pref.FieldDescriptor) string {
+		if field.Name() == "string_field" {
+			return "example_oneof"
+		}
+		return string(field.Name())
+	})
+	msg := &gen.Example{StringField: "s", ExampleOneof: &gen.Example_Int32Field{Int32Field: 7}}
+
+	assert.NotNil(ValidateFieldKeys(naming, msg.ProtoReflect().Descriptor()))
+	_, err := MarshalOptions{FieldNaming: naming, OneofMode: OneofWrapped}.Marshal(msg)
+	assert.NotNil(err)
+}
+
+func TestOneofNullResets(t *testing.T) {
+	assert := asrt.New(t)
+	docs := []bson.D{
+		{
+			{Key: "int32_field", Value: int32(1)},
+			{Key: "example_oneof", Value: nil},
+			{Key: "int64_field", Value: int64(2)},
+		},
+		{
+			{Key: "int32_field", Value: int32(1)},
+			{Key: "int32_field", Value: nil},
+			{Key: "int64_field", Value: int64(2)},
+		},
+	}
+	for _, doc := range docs {
+		bsonData, err := bson.Marshal(doc)
+		assert.Nil(err)
+		decoded := &gen.Example{}
+		assert.Nil(Unmarshal(bsonData, decoded))
+		assert.Equal(int64(2), decoded.GetInt64Field())
+	}
+}
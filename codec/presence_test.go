@@ -0,0 +1,71 @@
+package codec
+
+import (
+	"reflect"
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"google.golang.org/protobuf/proto"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestPresence(t *testing.T) {
+	assert := asrt.New(t)
+	msg := &gen.Scalars{OptionalInt32: proto.Int32(0), Int64Field: 5}
+
+	bsonData, err := Marshal(msg)
+	assert.Nil(err)
+	doc := bson.Raw(bsonData)
+	elements, err := doc.Elements()
+	assert.Nil(err)
+	assert.Len(elements, 2, "only populated fields must be written")
+	assert.Equal(int32(0), doc.Lookup("optional_int32").Int32(), "set optional field must be written")
+
+	decoded := &gen.Scalars{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.True(proto.Equal(msg, decoded))
+	assert.Nil(decoded.OptionalString)
+
+	bsonData, err = MarshalOptions{EmitUnpopulated: true}.Marshal(&gen.Scalars{})
+	assert.Nil(err)
+	doc = bson.Raw(bsonData)
+	assert.Equal(int32(0), doc.Lookup("int32_field").Int32())
+	assert.Equal(bsontype.Null, doc.Lookup("optional_string").Type)
+	assert.Equal(bsontype.Array, doc.Lookup("colors").Type)
+	assert.Equal(bsontype.EmbeddedDocument, doc.Lookup("color_map").Type)
+
+	bsonData, err = MarshalOptions{EmitNullForUnset: true}.Marshal(&gen.Scalars{})
+	assert.Nil(err)
+	doc = bson.Raw(bsonData)
+	assert.Equal(bsontype.Null, doc.Lookup("optional_int32").Type)
+	_, err = doc.LookupErr("int32_field")
+	assert.NotNil(err, "fields without presence must be omitted")
+}
+
+func TestDecodeNullClearsField(t *testing.T) {
+	assert := asrt.New(t)
+	bsonData, err := bson.Marshal(bson.D{
+		{Key: "int32_field", Value: nil},
+		{Key: "optional_string", Value: nil},
+		{Key: "colors", Value: nil},
+	})
+	assert.Nil(err)
+
+	decoded := &gen.Scalars{
+		Int32Field:     1,
+		OptionalString: proto.String("set"),
+		Colors:         []gen.Color{gen.Color_COLOR_RED},
+	}
+	// Unmarshal сбрасывает сообщение, поэтому декодирование идет поверх
+	// заполненного сообщения напрямую через кодек.
+	codec := &ProtobufMongoCodec{Registry: DefaultCodecsRegistry()}
+	reader := bsonrw.NewBSONDocumentReader(bsonData)
+	assert.Nil(codec.DecodeValue(DefaultDecContext, reader, reflect.ValueOf(decoded)))
+	assert.Equal(int32(0), decoded.Int32Field)
+	assert.Nil(decoded.OptionalString)
+	assert.Empty(decoded.Colors)
+}
@@ -2,20 +2,48 @@ package codec
 
 import (
 	"fmt"
-	"go.mongodb.org/mongo-driver/bson/bsontype"
-	"reflect"
 	"sync"
 
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+
 	"google.golang.org/protobuf/reflect/protoreflect"
-)
 
-var defaultCodecRegistry = DefaultCodecsRegistry()
+	protobson "bitbucket.org/entrlcom/proto-mongo/proto_bson"
+)
 
 const (
 	ProtobufKindList      = "protoreflect.List"
 	ProtobufKindMap       = "protoreflect.Map"
 	ProtobufKindMessage   = "protoreflect.Message"
 	ProtobufKindTimestamp = "google.protobuf.Timestamp"
+	ProtobufKindDuration  = "google.protobuf.Duration"
+
+	ProtobufKindDoubleValue = "google.protobuf.DoubleValue"
+	ProtobufKindFloatValue  = "google.protobuf.FloatValue"
+	ProtobufKindInt64Value  = "google.protobuf.Int64Value"
+	ProtobufKindUInt64Value = "google.protobuf.UInt64Value"
+	ProtobufKindInt32Value  = "google.protobuf.Int32Value"
+	ProtobufKindUInt32Value = "google.protobuf.UInt32Value"
+	ProtobufKindBoolValue   = "google.protobuf.BoolValue"
+	ProtobufKindStringValue = "google.protobuf.StringValue"
+	ProtobufKindBytesValue  = "google.protobuf.BytesValue"
+
+	ProtobufKindStruct    = "google.protobuf.Struct"
+	ProtobufKindValue     = "google.protobuf.Value"
+	ProtobufKindListValue = "google.protobuf.ListValue"
+	ProtobufKindAny       = "google.protobuf.Any"
+	ProtobufKindFieldMask = "google.protobuf.FieldMask"
+	ProtobufKindEmpty     = "google.protobuf.Empty"
+
+	ProtobufKindMoney   = "google.type.Money"
+	ProtobufKindDecimal = "google.type.Decimal"
+	ProtobufKindLatLng  = "google.type.LatLng"
+
+	// ProtobufKindGeoShape - кодек сообщений, размеченных опцией
+	// (proto_bson.message).geo_shape.
+	ProtobufKindGeoShape = "proto_bson.GeoShape"
 )
 
 // CodecsRegistry - реестр кодеков различных видов значений Protobuf'а.
@@ -26,28 +54,105 @@ type CodecsRegistry struct {
 	registry map[string]ProtoValueCodec
 
 	BasicCodec *protobufBasicCodec
+
+	// Настройки кодирования и декодирования, которыми руководствуются кодеки
+	// реестра. Нулевые значения соответствуют поведению по умолчанию.
+	// Настройки не должны меняться после того, как реестр начал использоваться:
+	// от них зависят закэшированные ключи полей.
+	MarshalOptions   MarshalOptions
+	UnmarshalOptions UnmarshalOptions
+
+	Config Config
+
+	// Ключи полей сообщений по дескрипторам, отдельно для кодирования и
+	// декодирования, т.к. стратегии именования в них могут отличаться.
+	encodeKeys *sync.Map
+	decodeKeys *sync.Map
 }
 
-func NewCodecsRegistry() *CodecsRegistry {
+// NewCodecsRegistry создает пустой реестр кодеков с настройками cfg.
+func NewCodecsRegistry(cfg Config) *CodecsRegistry {
 	r := &CodecsRegistry{
-		RWMutex:  new(sync.RWMutex),
-		registry: make(map[string]ProtoValueCodec),
+		RWMutex:    new(sync.RWMutex),
+		registry:   make(map[string]ProtoValueCodec),
+		Config:     cfg,
+		encodeKeys: new(sync.Map),
+		decodeKeys: new(sync.Map),
 	}
 	r.BasicCodec = newProtobufBasicCodec(r)
 	return r
 }
 
+// DefaultCodecsRegistry создает реестр со всеми кодеками пакета и настройками
+// по умолчанию.
 func DefaultCodecsRegistry() *CodecsRegistry {
-	r := NewCodecsRegistry()
+	return registerDefaultCodecs(NewCodecsRegistry(Config{}))
+}
+
+// registerDefaultCodecs регистрирует в r все кодеки пакета.
+func registerDefaultCodecs(r *CodecsRegistry) *CodecsRegistry {
 
 	_ = r.RegisterCodec(ProtobufKindMap, newProtobufMapCodec(r))
 	_ = r.RegisterCodec(ProtobufKindList, newProtobufListCodec(r))
 	_ = r.RegisterCodec(ProtobufKindMessage, newProtobufMessageCodec(r))
 	_ = r.RegisterCodec(ProtobufKindTimestamp, newProtobufTimestampCodec(r))
+	_ = r.RegisterCodec(ProtobufKindDuration, newProtobufDurationCodec(r))
+	wrapperCodec := newProtobufWrapperCodec(r)
+	for typeName := range wrapperTypes {
+		_ = r.RegisterCodec(string(typeName), wrapperCodec)
+	}
+	structCodec := newProtobufStructCodec(r)
+	_ = r.RegisterCodec(ProtobufKindStruct, structCodec)
+	_ = r.RegisterCodec(ProtobufKindValue, structCodec)
+	_ = r.RegisterCodec(ProtobufKindListValue, structCodec)
+	_ = r.RegisterCodec(ProtobufKindAny, newProtobufAnyCodec(r))
+	_ = r.RegisterCodec(ProtobufKindFieldMask, newProtobufFieldMaskCodec(r))
+	_ = r.RegisterCodec(ProtobufKindEmpty, newProtobufEmptyCodec(r))
+	_ = r.RegisterCodec(ProtobufKindMoney, newProtobufMoneyCodec(r))
+	_ = r.RegisterCodec(ProtobufKindDecimal, newProtobufDecimalCodec(r))
+	geoJSONCodec := newProtobufGeoJSONCodec(r)
+	_ = r.RegisterCodec(ProtobufKindLatLng, geoJSONCodec)
+	_ = r.RegisterCodec(ProtobufKindGeoShape, geoJSONCodec)
 
 	return r
 }
 
+// encodeFieldKeys возвращает ключи полей сообщения md при кодировании, см.
+// messageFieldKeys.
+func (r *CodecsRegistry) encodeFieldKeys(md protoreflect.MessageDescriptor) (map[string]keyedField, error) {
+	return cachedFieldKeys(r.encodeKeys, r.MarshalOptions.FieldNaming, md)
+}
+
+// decodeFieldKeys возвращает ключи полей сообщения md при декодировании, см.
+// messageFieldKeys.
+func (r *CodecsRegistry) decodeFieldKeys(md protoreflect.MessageDescriptor) (map[string]keyedField, error) {
+	return cachedFieldKeys(r.decodeKeys, r.UnmarshalOptions.FieldNaming, md)
+}
+
+// cachedFieldKeys возвращает результат messageFieldKeys из cache, вычисляя его
+// при первом обращении. Ошибки не кэшируются.
+func cachedFieldKeys(
+	cache *sync.Map, naming NamingStrategy, md protoreflect.MessageDescriptor,
+) (map[string]keyedField, error) {
+	if keys, ok := cache.Load(md); ok {
+		return keys.(map[string]keyedField), nil
+	}
+	keys, err := messageFieldKeys(naming, md)
+	if err != nil {
+		return nil, err
+	}
+	cache.Store(md, keys)
+	return keys, nil
+}
+
+// logger возвращает журнал из настроек реестра либо nopLogger.
+func (r *CodecsRegistry) logger() Logger {
+	if r.Config.Logger == nil {
+		return nopLogger{}
+	}
+	return r.Config.Logger
+}
+
 func (r *CodecsRegistry) RegisterCodec(typeName string, codec ProtoValueCodec) error {
 	r.Lock()
 	defer r.Unlock()
@@ -99,51 +204,12 @@ func (r *CodecsRegistry) GetCodecByValue(value protoreflect.Value) (codec ProtoV
 	if !value.IsValid() {
 		return nil, false
 	}
-	// TODO: усптростить детект типа значения value protoreflect.Value.
-	isValueMessage := func(val protoreflect.Value) (isMessage bool) {
-		defer func() {
-			if r := recover(); r != nil {
-				isMessage = false
-			}
-		}()
-		val.Message()
-		isMessage = true
-		return isMessage
-	}
-	isValueList := func(val protoreflect.Value) (isList bool) {
-		defer func() {
-			if r := recover(); r != nil {
-				isList = false
-			}
-		}()
-		val.List()
-		isList = true
-		return isList
-	}
-	isValueMap := func(val protoreflect.Value) (isMap bool) {
-		defer func() {
-			if r := recover(); r != nil {
-				isMap = false
-			}
-		}()
-		val.Map()
-		isMap = true
-		return isMap
-	}
-
-	switch {
-	case isValueMessage(value):
-		codec, ok = r.GetCodecForMessage(value.Message().Descriptor())
-	case isValueList(value):
+	switch v := value.Interface().(type) {
+	case protoreflect.Message:
+		codec, ok = r.GetCodecForMessage(v.Descriptor())
+	case protoreflect.List:
 		codec, ok = r.GetCodec(ProtobufKindList)
-	case isValueMap(value):
-		_, ok = value.Interface().(protoreflect.Map)
-		fmt.Println(ok)
-		if ok {
-			protoreflectMapType := reflect.TypeOf(protoreflect.Map(nil))
-			isMap := reflect.TypeOf(value.Interface()).Implements(protoreflectMapType)
-			fmt.Println(isMap)
-		}
+	case protoreflect.Map:
 		codec, ok = r.GetCodec(ProtobufKindMap)
 	}
 
@@ -161,6 +227,69 @@ func (r *CodecsRegistry) GetCodecForMessage(
 	if specificCodec, ok := r.GetCodec(string(msg.FullName())); ok {
 		return specificCodec, true
 	}
+	// Сообщения, размеченные как GeoJSON фигуры, кодируются общим кодеком фигур.
+	if getMessageOptions(msg).GetGeoShape() != protobson.GeoShape_GEO_SHAPE_UNSPECIFIED {
+		if geoCodec, ok := r.GetCodec(ProtobufKindGeoShape); ok {
+			return geoCodec, true
+		}
+	}
 	codec, ok := r.GetCodec(ProtobufKindMessage)
 	return codec, ok
 }
+
+// encodeFieldValue кодирует значение val поля fd кодеком, подходящим для поля,
+// либо кодеком базовых типов.
+func (r *CodecsRegistry) encodeFieldValue(
+	ctx bsoncodec.EncodeContext, w bsonrw.ValueWriter, fd protoreflect.FieldDescriptor,
+	val protoreflect.Value,
+) error {
+	codec, ok := r.GetCodecByField(fd)
+	if !ok {
+		return r.BasicCodec.encodeScalar(ctx, w, fd, val)
+	}
+	if fieldCodec, ok := codec.(fieldValueCodec); ok {
+		return fieldCodec.encodeFieldValue(ctx, w, fd, val)
+	}
+	return codec.EncodeValue(ctx, w, val)
+}
+
+// decodeFieldValue декодирует значение поля fd в val - новое значение поля, и
+// возвращает результат. Составные значения заполняются на месте, скалярные -
+// возвращаются новым значением.
+func (r *CodecsRegistry) decodeFieldValue(
+	ctx bsoncodec.DecodeContext, vr bsonrw.ValueReader, fd protoreflect.FieldDescriptor,
+	val protoreflect.Value,
+) (protoreflect.Value, error) {
+	codec, ok := r.GetCodecByField(fd)
+	if !ok {
+		return r.BasicCodec.decodeScalar(ctx, vr, fd, val)
+	}
+	if fieldCodec, ok := codec.(fieldValueCodec); ok {
+		return val, fieldCodec.decodeFieldValue(ctx, vr, fd, val)
+	}
+	return val, codec.DecodeValue(ctx, vr, val)
+}
+
+// encodeElement кодирует элемент списка или значение мапы val. fd - поле, вид
+// значений которого совпадает с val, либо nil, если поле неизвестно.
+func (r *CodecsRegistry) encodeElement(
+	ctx bsoncodec.EncodeContext, w bsonrw.ValueWriter, fd protoreflect.FieldDescriptor,
+	val protoreflect.Value,
+) error {
+	if codec, ok := r.GetCodecByValue(val); ok {
+		return codec.EncodeValue(ctx, w, val)
+	}
+	return r.BasicCodec.encodeScalar(ctx, w, fd, val)
+}
+
+// decodeElement декодирует элемент списка или значение мапы в val и возвращает
+// результат, как и decodeFieldValue.
+func (r *CodecsRegistry) decodeElement(
+	ctx bsoncodec.DecodeContext, vr bsonrw.ValueReader, fd protoreflect.FieldDescriptor,
+	val protoreflect.Value,
+) (protoreflect.Value, error) {
+	if codec, ok := r.GetCodecByValue(val); ok {
+		return val, codec.DecodeValue(ctx, vr, val)
+	}
+	return r.BasicCodec.decodeScalar(ctx, vr, fd, val)
+}
@@ -0,0 +1,160 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+	"google.golang.org/protobuf/proto"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestStrictDecoding(t *testing.T) {
+	assert := asrt.New(t)
+	strict := UnmarshalOptions{Strict: true}
+
+	tests := []struct {
+		path string
+		doc  bson.D
+		// lenient - декодирование без Strict проходит успешно.
+		lenient bool
+	}{
+		{
+			path: "nested_message.nested_int32_field",
+			doc:  bson.D{{Key: "nested_message", Value: bson.D{{Key: "nested_int32_field", Value: "seven"}}}},
+		},
+		{
+			path:    "nested_message.unknown",
+			doc:     bson.D{{Key: "nested_message", Value: bson.D{{Key: "unknown", Value: 1}}}},
+			lenient: true,
+		},
+		{path: "str_array.1", doc: bson.D{{Key: "str_array", Value: bson.A{"a", int32(1)}}}},
+		{path: "projects.dev", doc: bson.D{{Key: "projects", Value: bson.D{{Key: "dev", Value: "yes"}}}}},
+		{
+			path: "string_field",
+			doc: bson.D{
+				{Key: "string_field", Value: "a"},
+				{Key: "string_field", Value: "b"},
+			},
+			lenient: true,
+		},
+		{
+			path:    "projects.dev",
+			doc:     bson.D{{Key: "projects", Value: bson.D{{Key: "dev", Value: true}, {Key: "dev", Value: false}}}},
+			lenient: true,
+		},
+	}
+	for _, test := range tests {
+		bsonData, err := bson.Marshal(test.doc)
+		assert.Nil(err)
+		err = Unmarshal(bsonData, &gen.Example{})
+		if test.lenient {
+			assert.Nil(err, "lenient decoding must skip %s", test.path)
+		} else {
+			assert.NotNil(err, "type mismatch at %s must be an error", test.path)
+		}
+
+		err = strict.Unmarshal(bsonData, &gen.Example{})
+		var decodeErr *DecodeError
+		if assert.True(errors.As(err, &decodeErr), test.path) {
+			assert.Equal(test.path, decodeErr.Path)
+		}
+	}
+
+	invalidUTF8 := bsoncore.BuildDocument(nil, bsoncore.AppendStringElement(nil, "string_field", "\xff"))
+	assert.Nil(Unmarshal(invalidUTF8, &gen.Example{}))
+	err := strict.Unmarshal(invalidUTF8, &gen.Example{})
+	assert.EqualError(err, "can't decode string_field of Example (string from BSON string): string is not valid UTF-8")
+}
+
+func TestStrictTypes(t *testing.T) {
+	assert := asrt.New(t)
+	strict := UnmarshalOptions{Strict: true}
+	oid := primitive.NewObjectID()
+
+	tests := []struct {
+		path string
+		doc  bson.D
+		msg  proto.Message
+	}{
+		{path: "string_field", doc: bson.D{{Key: "string_field", Value: oid}}, msg: &gen.Example{}},
+		{path: "string_field", doc: bson.D{{Key: "string_field", Value: primitive.Symbol("s")}}, msg: &gen.Example{}},
+		{path: "string_field", doc: bson.D{{Key: "string_field", Value: primitive.Binary{Data: []byte("s")}}}, msg: &gen.Example{}},
+		{path: "data", doc: bson.D{{Key: "data", Value: "AQID"}}, msg: &gen.Scalars{}},
+		{path: "string_value", doc: bson.D{{Key: "string_value", Value: oid}}, msg: &gen.WellKnownTypes{}},
+	}
+	for _, test := range tests {
+		bsonData, err := bson.Marshal(test.doc)
+		assert.Nil(err)
+		err = strict.Unmarshal(bsonData, test.msg)
+		var decodeErr *DecodeError
+		if assert.True(errors.As(err, &decodeErr), "%s: %v", test.path, test.doc) {
+			assert.Equal(test.path, decodeErr.Path)
+		}
+	}
+
+	// UUID поля принимают Binary с подтипом UUID.
+	bsonData, err := bson.Marshal(bson.D{{Key: "uuid", Value: primitive.Binary{
+		Subtype: bsontype.BinaryUUID, Data: make([]byte, 16),
+	}}})
+	assert.Nil(err)
+	decoded := &gen.Scalars{}
+	assert.Nil(strict.Unmarshal(bsonData, decoded))
+	assert.Equal("00000000-0000-0000-0000-000000000000", decoded.Uuid)
+}
+
+func TestStrictStruct(t *testing.T) {
+	assert := asrt.New(t)
+	strict := UnmarshalOptions{Strict: true}
+
+	tests := []struct {
+		path string
+		doc  []byte
+	}{
+		{
+			path: "struct.a",
+			doc: bsoncore.BuildDocument(nil, bsoncore.AppendDocumentElement(nil, "struct", bsoncore.BuildDocument(nil,
+				bsoncore.AppendStringElement(bsoncore.AppendStringElement(nil, "a", "1"), "a", "2"),
+			))),
+		},
+		{
+			path: "struct.a",
+			doc: bsoncore.BuildDocument(nil, bsoncore.AppendDocumentElement(nil, "struct", bsoncore.BuildDocument(nil,
+				bsoncore.AppendStringElement(nil, "a", "\xff"),
+			))),
+		},
+		{
+			path: "value.list.0.\xff",
+			doc: bsoncore.BuildDocument(nil, bsoncore.AppendDocumentElement(nil, "value", bsoncore.BuildDocument(nil,
+				bsoncore.AppendArrayElement(nil, "list", bsoncore.BuildArray(nil, bsoncore.Value{
+					Type: bsontype.EmbeddedDocument,
+					Data: bsoncore.BuildDocument(nil, bsoncore.AppendNullElement(nil, "\xff")),
+				})),
+			))),
+		},
+	}
+	for _, test := range tests {
+		assert.Nil(Unmarshal(test.doc, &gen.WellKnownTypes{}), "lenient decoding must accept %s", test.path)
+		err := strict.Unmarshal(test.doc, &gen.WellKnownTypes{})
+		var decodeErr *DecodeError
+		if assert.True(errors.As(err, &decodeErr), test.path) {
+			assert.Equal(test.path, decodeErr.Path)
+		}
+	}
+}
+
+func TestStrictNumericCoercion(t *testing.T) {
+	assert := asrt.New(t)
+	bsonData, err := bson.Marshal(bson.D{{Key: "int32_field", Value: 7.0}})
+	assert.Nil(err)
+
+	// Числовые поля подчиняются NumericCoercion, а не Strict.
+	decoded := &gen.Scalars{}
+	assert.Nil(UnmarshalOptions{Strict: true}.Unmarshal(bsonData, decoded))
+	assert.Equal(int32(7), decoded.Int32Field)
+}
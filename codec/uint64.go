@@ -0,0 +1,123 @@
+package codec
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+
+	protobson "bitbucket.org/entrlcom/proto-mongo/proto_bson"
+)
+
+// Uint64Mode задает BSON представление uint64 и fixed64 значений, для которых в
+// BSON нет своего типа.
+type Uint64Mode int
+
+const (
+	// Uint64Int64 - int64 с тем же набором бит (дополнительный код). Значения
+	// больше MaxInt64 хранятся отрицательными. Используется по умолчанию.
+	Uint64Int64 Uint64Mode = iota
+	// Uint64Decimal128 - Decimal128 с точным значением.
+	Uint64Decimal128
+	// Uint64String - десятичная строка.
+	Uint64String
+)
+
+// uint64Mode возвращает представление uint64 значений поля fd: опция поля
+// (proto_bson.field).uint64_mode имеет приоритет над настройкой кодирования.
+// fd может быть nil, если поле неизвестно.
+func (pc *protobufBasicCodec) uint64Mode(fd pref.FieldDescriptor) Uint64Mode {
+	if fd != nil {
+		switch getFieldOptions(fd).GetUint64Mode() {
+		case protobson.Uint64Mode_UINT64_MODE_INT64:
+			return Uint64Int64
+		case protobson.Uint64Mode_UINT64_MODE_DECIMAL128:
+			return Uint64Decimal128
+		case protobson.Uint64Mode_UINT64_MODE_STRING:
+			return Uint64String
+		}
+	}
+	return pc.registry.MarshalOptions.Uint64Mode
+}
+
+func encodeUint64(w bsonrw.ValueWriter, mode Uint64Mode, u uint64) error {
+	switch mode {
+	case Uint64Int64:
+		return w.WriteInt64(int64(u))
+	case Uint64Decimal128:
+		d, err := primitive.ParseDecimal128(strconv.FormatUint(u, 10))
+		if err != nil {
+			return err
+		}
+		return w.WriteDecimal128(d)
+	case Uint64String:
+		return w.WriteString(strconv.FormatUint(u, 10))
+	}
+	return fmt.Errorf("unknown uint64 mode %d", mode)
+}
+
+// decodeUint64 читает uint64 в любом из представлений Uint64Mode, независимо от
+// настроек. Значения, которые не помещаются в uint64 без потерь, - ошибка.
+func decodeUint64(r bsonrw.ValueReader) (uint64, error) {
+	switch r.Type() {
+	case bsontype.Int64:
+		i, err := r.ReadInt64()
+		return uint64(i), err
+	case bsontype.Int32:
+		i, err := r.ReadInt32()
+		if err != nil {
+			return 0, err
+		}
+		if i < 0 {
+			return 0, fmt.Errorf("int32 %d overflows uint64", i)
+		}
+		return uint64(i), nil
+	case bsontype.Decimal128:
+		d, err := r.ReadDecimal128()
+		if err != nil {
+			return 0, err
+		}
+		return decimal128ToUint64(d)
+	case bsontype.String:
+		str, err := r.ReadString()
+		if err != nil {
+			return 0, err
+		}
+		u, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("string %q is not a uint64: %w", str, err)
+		}
+		return u, nil
+	}
+	return 0, fmt.Errorf("can't decode uint64 from BSON %s", r.Type())
+}
+
+// decimal128ToUint64 переводит d в uint64, если d - целое число в диапазоне
+// uint64.
+func decimal128ToUint64(d primitive.Decimal128) (uint64, error) {
+	coef, exp, err := d.BigInt()
+	if err != nil {
+		return 0, fmt.Errorf("decimal128 %s is not a uint64: %w", d, err)
+	}
+	ten := big.NewInt(10)
+	for ; exp > 0 && coef.Sign() != 0; exp-- {
+		if coef.Mul(coef, ten); !coef.IsUint64() {
+			return 0, fmt.Errorf("decimal128 %s overflows uint64", d)
+		}
+	}
+	for ; exp < 0; exp++ {
+		var mod big.Int
+		coef.QuoRem(coef, ten, &mod)
+		if mod.Sign() != 0 {
+			return 0, fmt.Errorf("decimal128 %s is not an integer", d)
+		}
+	}
+	if coef.Sign() < 0 || !coef.IsUint64() {
+		return 0, fmt.Errorf("decimal128 %s overflows uint64", d)
+	}
+	return coef.Uint64(), nil
+}
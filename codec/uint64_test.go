@@ -0,0 +1,94 @@
+package codec
+
+import (
+	"math"
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/proto"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestUint64Modes(t *testing.T) {
+	assert := asrt.New(t)
+	msg := &gen.Scalars{
+		Uint64Field:   math.MaxUint64,
+		Fixed64Field:  math.MaxInt64 + 1,
+		DecimalUint64: math.MaxUint64,
+		Uint64List:    []uint64{0, math.MaxUint64},
+		Uint64Map:     map[string]uint64{"max": math.MaxUint64},
+	}
+
+	for _, mode := range []Uint64Mode{Uint64Int64, Uint64Decimal128, Uint64String} {
+		bsonData, err := MarshalOptions{Uint64Mode: mode}.Marshal(msg)
+		assert.Nil(err)
+		decoded := &gen.Scalars{}
+		assert.Nil(Unmarshal(bsonData, decoded))
+		assert.True(proto.Equal(msg, decoded), "mode %d", mode)
+	}
+
+	bsonData, err := Marshal(msg)
+	assert.Nil(err)
+	doc := bson.Raw(bsonData)
+	assert.Equal(int64(-1), doc.Lookup("uint64_field").Int64())
+	assert.Equal(bsontype.Decimal128, doc.Lookup("decimal_uint64").Type)
+	assert.Equal("18446744073709551615", doc.Lookup("uint64_list", "1").StringValue())
+	assert.Equal("18446744073709551615", doc.Lookup("uint64_map", "max").StringValue())
+}
+
+func TestDecodeUint64(t *testing.T) {
+	assert := asrt.New(t)
+	decimal := func(s string) primitive.Decimal128 {
+		d, err := primitive.ParseDecimal128(s)
+		assert.Nil(err)
+		return d
+	}
+
+	valid := map[string]interface{}{
+		"int32":    int32(7),
+		"decimal":  decimal("7"),
+		"exponent": decimal("0.07E+2"),
+		"string":   "7",
+	}
+	for name, value := range valid {
+		u, err := decodeUint64(bsonValueReader(t, value))
+		assert.Nil(err, name)
+		assert.Equal(uint64(7), u, name)
+	}
+
+	invalid := map[string]interface{}{
+		"negative int32":   int32(-1),
+		"fraction":         decimal("7.5"),
+		"negative decimal": decimal("-7"),
+		"large decimal":    decimal("18446744073709551616"),
+		"large string":     "18446744073709551616",
+		"double":           7.0,
+	}
+	for name, value := range invalid {
+		_, err := decodeUint64(bsonValueReader(t, value))
+		assert.NotNil(err, name)
+	}
+}
+
+// bsonValueReader возвращает читателя BSON значения value.
+func bsonValueReader(t *testing.T, value interface{}) bsonrw.ValueReader {
+	t.Helper()
+	bsonData, err := bson.Marshal(bson.D{{Key: "v", Value: value}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dr, err := bsonrw.NewBSONDocumentReader(bsonData).ReadDocument()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, vr, err := dr.ReadElement()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vr
+}
@@ -0,0 +1,151 @@
+package codec
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+	"google.golang.org/protobuf/encoding/protowire"
+	pref "google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// unknownBSONFieldNumber - номер поля, под которым неизвестные элементы
+// документа хранятся в неизвестных полях (unknown fields) сообщения. Каждый
+// элемент - отдельная запись с BSON документом из этого элемента.
+//
+// Это обычный допустимый номер поля, который пакет резервирует за собой: в
+// хранимых сообщениях, декодируемых с PreserveUnknown, его нельзя объявлять
+// (лучше явно написать reserved 536870911). Неизвестные поля переживают
+// proto.Marshal, т.е. сохраненные элементы уходят и тем, кто получает
+// сообщение в бинарном виде. Для них это неизвестное поле, но если в их схеме
+// этот номер объявлен, то BSON будет прочитан как значение этого поля.
+const unknownBSONFieldNumber = protowire.MaxValidNumber
+
+// checkUnknownFieldNumber проверяет, что в сообщении md не объявлено поле с
+// номером unknownBSONFieldNumber.
+func checkUnknownFieldNumber(md pref.MessageDescriptor) error {
+	if field := md.Fields().ByNumber(unknownBSONFieldNumber); field != nil {
+		return fmt.Errorf(
+			"field %s uses number %d reserved for preserved BSON elements",
+			field.FullName(), unknownBSONFieldNumber,
+		)
+	}
+	return nil
+}
+
+// preserveUnknown сохраняет элемент key, значение которого читает r, в
+// неизвестных полях msg. Элемент с тем же ключом, сохраненный раньше, например,
+// при декодировании в то же сообщение хуком драйвера, заменяется.
+func preserveUnknown(msg pref.Message, key string, r bsonrw.ValueReader) error {
+	if err := checkUnknownFieldNumber(msg.Descriptor()); err != nil {
+		return err
+	}
+	t, data, err := bsonrw.Copier{}.CopyValueToBytes(r)
+	if err != nil {
+		return err
+	}
+	doc := bsoncore.BuildDocument(nil, bsoncore.AppendValueElement(nil, key, bsoncore.Value{
+		Type: t, Data: data,
+	}))
+	unknown, err := removePreserved(msg.GetUnknown(), key)
+	if err != nil {
+		return err
+	}
+	unknown = protowire.AppendTag(unknown, unknownBSONFieldNumber, protowire.BytesType)
+	unknown = protowire.AppendBytes(unknown, doc)
+	msg.SetUnknown(unknown)
+	return nil
+}
+
+// removePreserved возвращает неизвестные поля unknown без элементов с ключом
+// key, сохраненных preserveUnknown. Остальные поля не меняются.
+func removePreserved(unknown []byte, key string) ([]byte, error) {
+	var kept []byte
+	for len(unknown) > 0 {
+		num, typ, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		m := protowire.ConsumeFieldValue(num, typ, unknown[n:])
+		if m < 0 {
+			return nil, protowire.ParseError(m)
+		}
+		field := unknown[:n+m]
+		unknown = unknown[n+m:]
+		if num == unknownBSONFieldNumber && typ == protowire.BytesType {
+			doc, _ := protowire.ConsumeBytes(field[n:])
+			if element, err := bson.Raw(doc).IndexErr(0); err == nil && element.Key() == key {
+				continue
+			}
+		}
+		kept = append(kept, field...)
+	}
+	return kept, nil
+}
+
+// preservedUnknown возвращает элементы документа, сохраненные preserveUnknown
+// в неизвестных полях msg, в порядке сохранения.
+func preservedUnknown(msg pref.Message) ([]bson.RawElement, error) {
+	var elements []bson.RawElement
+	for unknown := msg.GetUnknown(); len(unknown) > 0; {
+		num, typ, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		unknown = unknown[n:]
+		if num != unknownBSONFieldNumber || typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, unknown)
+			if n < 0 {
+				return nil, protowire.ParseError(n)
+			}
+			unknown = unknown[n:]
+			continue
+		}
+		doc, n := protowire.ConsumeBytes(unknown)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		unknown = unknown[n:]
+		docElements, err := bson.Raw(doc).Elements()
+		if err != nil {
+			return nil, fmt.Errorf("invalid preserved BSON element: %w", err)
+		}
+		elements = append(elements, docElements...)
+	}
+	return elements, nil
+}
+
+// encodeUnknown пишет в dw элементы, сохраненные в неизвестных полях msg при
+// декодировании. Элементы, ключи которых совпадают с ключами полей msg,
+// пропускаются, чтобы в документе не было повторов. Из элементов с одним
+// ключом, например, попавших в msg через proto.Merge, пишется последний.
+func (pc *protobufMessageCodec) encodeUnknown(dw bsonrw.DocumentWriter, msg pref.Message) error {
+	elements, err := preservedUnknown(msg)
+	if err != nil || len(elements) == 0 {
+		return err
+	}
+	fieldKeys, err := pc.registry.encodeFieldKeys(msg.Descriptor())
+	if err != nil {
+		return err
+	}
+	last := make(map[string]int, len(elements))
+	for i, element := range elements {
+		last[element.Key()] = i
+	}
+	for i, element := range elements {
+		key := element.Key()
+		if _, ok := fieldKeys[key]; ok || last[key] != i {
+			continue
+		}
+		vw, err := dw.WriteDocumentElement(key)
+		if err != nil {
+			return err
+		}
+		value := element.Value()
+		if err = (bsonrw.Copier{}).CopyValueFromBytes(vw, value.Type, value.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package codec
+
+import (
+	"testing"
+
+	asrt "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"bitbucket.org/entrlcom/proto-mongo/gen"
+)
+
+func TestPreserveUnknown(t *testing.T) {
+	assert := asrt.New(t)
+	bsonData, err := bson.Marshal(bson.D{
+		{Key: "int32_field", Value: int32(1)},
+		{Key: "added_later", Value: bson.D{{Key: "nested", Value: bson.A{"a", int64(2)}}}},
+		{Key: "flag", Value: true},
+	})
+	assert.Nil(err)
+
+	decoded := &gen.Scalars{}
+	assert.Nil(Unmarshal(bsonData, decoded))
+	assert.Empty(decoded.ProtoReflect().GetUnknown(), "unknown keys are dropped by default")
+
+	decoded = &gen.Scalars{}
+	assert.Nil(UnmarshalOptions{PreserveUnknown: true}.Unmarshal(bsonData, decoded))
+	assert.Equal(int32(1), decoded.Int32Field)
+
+	// Неизвестные элементы переживают и бинарную сериализацию Protobuf'а.
+	binData, err := proto.Marshal(decoded)
+	assert.Nil(err)
+	restored := &gen.Scalars{}
+	assert.Nil(proto.Unmarshal(binData, restored))
+
+	encoded, err := Marshal(restored)
+	assert.Nil(err)
+	assert.Equal(bson.Raw(bsonData).String(), bson.Raw(encoded).String())
+}
+
+func TestPreserveUnknownReservedNumber(t *testing.T) {
+	assert := asrt.New(t)
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("reserved_test.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Clash"), Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("last"),
+				JsonName: proto.String("last"),
+				Number:   proto.Int32(536870911),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_BYTES.Enum(),
+			}}},
+		},
+	}, nil)
+	if !assert.Nil(err) {
+		return
+	}
+	md := fd.Messages().ByName("Clash")
+	assert.NotNil(ValidateFieldKeys(nil, md))
+
+	bsonData, err := bson.Marshal(bson.D{{Key: "added_later", Value: 1}})
+	assert.Nil(err)
+	err = UnmarshalOptions{PreserveUnknown: true}.Unmarshal(bsonData, dynamicpb.NewMessage(md))
+	assert.NotNil(err)
+}
+
+func TestPreserveUnknownDecodeTwice(t *testing.T) {
+	assert := asrt.New(t)
+	pc := NewProtobufMongoCodec(Config{})
+	pc.Registry.UnmarshalOptions.PreserveUnknown = true
+	registry := NewBSONRegistry(pc)
+
+	bsonData, err := bson.Marshal(bson.D{
+		{Key: "int32_field", Value: int32(1)},
+		{Key: "added_later", Value: "a"},
+	})
+	assert.Nil(err)
+	// Хук драйвера декодирует в существующее сообщение, не сбрасывая его.
+	decoded := &gen.Scalars{}
+	assert.Nil(bson.UnmarshalWithRegistry(registry, bsonData, decoded))
+	assert.Nil(bson.UnmarshalWithRegistry(registry, bsonData, decoded))
+
+	encoded, err := Marshal(decoded)
+	assert.Nil(err)
+	assert.Equal(bson.Raw(bsonData).String(), bson.Raw(encoded).String())
+
+	// При повторе ключа в неизвестных полях пишется последний элемент.
+	merged := proto.Clone(decoded).(*gen.Scalars)
+	other := &gen.Scalars{}
+	otherData, err := bson.Marshal(bson.D{{Key: "added_later", Value: "b"}})
+	assert.Nil(err)
+	assert.Nil(UnmarshalOptions{PreserveUnknown: true}.Unmarshal(otherData, other))
+	proto.Merge(merged, other)
+	encoded, err = Marshal(merged)
+	assert.Nil(err)
+	value, err := bson.Raw(encoded).LookupErr("added_later")
+	assert.Nil(err)
+	assert.Equal("b", value.StringValue())
+	elements, err := bson.Raw(encoded).Elements()
+	assert.Nil(err)
+	assert.Len(elements, 2)
+}
@@ -0,0 +1,444 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.17.3
+// source: gen/scalars.proto
+
+package gen
+
+import (
+	_ "bitbucket.org/entrlcom/proto-mongo/proto_bson"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Color int32
+
+const (
+	Color_COLOR_UNSPECIFIED Color = 0
+	Color_COLOR_RED         Color = 1
+	Color_COLOR_GREEN       Color = 2
+)
+
+// Enum value maps for Color.
+var (
+	Color_name = map[int32]string{
+		0: "COLOR_UNSPECIFIED",
+		1: "COLOR_RED",
+		2: "COLOR_GREEN",
+	}
+	Color_value = map[string]int32{
+		"COLOR_UNSPECIFIED": 0,
+		"COLOR_RED":         1,
+		"COLOR_GREEN":       2,
+	}
+)
+
+func (x Color) Enum() *Color {
+	p := new(Color)
+	*p = x
+	return p
+}
+
+func (x Color) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Color) Descriptor() protoreflect.EnumDescriptor {
+	return file_gen_scalars_proto_enumTypes[0].Descriptor()
+}
+
+func (Color) Type() protoreflect.EnumType {
+	return &file_gen_scalars_proto_enumTypes[0]
+}
+
+func (x Color) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Color.Descriptor instead.
+func (Color) EnumDescriptor() ([]byte, []int) {
+	return file_gen_scalars_proto_rawDescGZIP(), []int{0}
+}
+
+type Scalars struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uint64Field    uint64            `protobuf:"varint,1,opt,name=uint64_field,json=uint64Field,proto3" json:"uint64_field,omitempty"`
+	Fixed64Field   uint64            `protobuf:"fixed64,2,opt,name=fixed64_field,json=fixed64Field,proto3" json:"fixed64_field,omitempty"`
+	DecimalUint64  uint64            `protobuf:"varint,3,opt,name=decimal_uint64,json=decimalUint64,proto3" json:"decimal_uint64,omitempty"`
+	Uint64List     []uint64          `protobuf:"varint,4,rep,packed,name=uint64_list,json=uint64List,proto3" json:"uint64_list,omitempty"`
+	Uint64Map      map[string]uint64 `protobuf:"bytes,5,rep,name=uint64_map,json=uint64Map,proto3" json:"uint64_map,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	Color          Color             `protobuf:"varint,6,opt,name=color,proto3,enum=gen.Color" json:"color,omitempty"`
+	Colors         []Color           `protobuf:"varint,7,rep,packed,name=colors,proto3,enum=gen.Color" json:"colors,omitempty"`
+	ColorMap       map[string]Color  `protobuf:"bytes,8,rep,name=color_map,json=colorMap,proto3" json:"color_map,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3,enum=gen.Color"`
+	Int32Field     int32             `protobuf:"varint,9,opt,name=int32_field,json=int32Field,proto3" json:"int32_field,omitempty"`
+	Int64Field     int64             `protobuf:"varint,10,opt,name=int64_field,json=int64Field,proto3" json:"int64_field,omitempty"`
+	FloatField     float32           `protobuf:"fixed32,11,opt,name=float_field,json=floatField,proto3" json:"float_field,omitempty"`
+	DoubleField    float64           `protobuf:"fixed64,12,opt,name=double_field,json=doubleField,proto3" json:"double_field,omitempty"`
+	BoolField      bool              `protobuf:"varint,13,opt,name=bool_field,json=boolField,proto3" json:"bool_field,omitempty"`
+	Data           []byte            `protobuf:"bytes,14,opt,name=data,proto3" json:"data,omitempty"`
+	UuidBytes      []byte            `protobuf:"bytes,15,opt,name=uuid_bytes,json=uuidBytes,proto3" json:"uuid_bytes,omitempty"`
+	Uuid           string            `protobuf:"bytes,16,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Uuids          []string          `protobuf:"bytes,17,rep,name=uuids,proto3" json:"uuids,omitempty"`
+	OptionalInt32  *int32            `protobuf:"varint,18,opt,name=optional_int32,json=optionalInt32,proto3,oneof" json:"optional_int32,omitempty"`
+	OptionalString *string           `protobuf:"bytes,19,opt,name=optional_string,json=optionalString,proto3,oneof" json:"optional_string,omitempty"`
+	Int32Map       map[int32]string  `protobuf:"bytes,20,rep,name=int32_map,json=int32Map,proto3" json:"int32_map,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	BoolMap        map[bool]int32    `protobuf:"bytes,21,rep,name=bool_map,json=boolMap,proto3" json:"bool_map,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+}
+
+func (x *Scalars) Reset() {
+	*x = Scalars{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_gen_scalars_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Scalars) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Scalars) ProtoMessage() {}
+
+func (x *Scalars) ProtoReflect() protoreflect.Message {
+	mi := &file_gen_scalars_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Scalars.ProtoReflect.Descriptor instead.
+func (*Scalars) Descriptor() ([]byte, []int) {
+	return file_gen_scalars_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Scalars) GetUint64Field() uint64 {
+	if x != nil {
+		return x.Uint64Field
+	}
+	return 0
+}
+
+func (x *Scalars) GetFixed64Field() uint64 {
+	if x != nil {
+		return x.Fixed64Field
+	}
+	return 0
+}
+
+func (x *Scalars) GetDecimalUint64() uint64 {
+	if x != nil {
+		return x.DecimalUint64
+	}
+	return 0
+}
+
+func (x *Scalars) GetUint64List() []uint64 {
+	if x != nil {
+		return x.Uint64List
+	}
+	return nil
+}
+
+func (x *Scalars) GetUint64Map() map[string]uint64 {
+	if x != nil {
+		return x.Uint64Map
+	}
+	return nil
+}
+
+func (x *Scalars) GetColor() Color {
+	if x != nil {
+		return x.Color
+	}
+	return Color_COLOR_UNSPECIFIED
+}
+
+func (x *Scalars) GetColors() []Color {
+	if x != nil {
+		return x.Colors
+	}
+	return nil
+}
+
+func (x *Scalars) GetColorMap() map[string]Color {
+	if x != nil {
+		return x.ColorMap
+	}
+	return nil
+}
+
+func (x *Scalars) GetInt32Field() int32 {
+	if x != nil {
+		return x.Int32Field
+	}
+	return 0
+}
+
+func (x *Scalars) GetInt64Field() int64 {
+	if x != nil {
+		return x.Int64Field
+	}
+	return 0
+}
+
+func (x *Scalars) GetFloatField() float32 {
+	if x != nil {
+		return x.FloatField
+	}
+	return 0
+}
+
+func (x *Scalars) GetDoubleField() float64 {
+	if x != nil {
+		return x.DoubleField
+	}
+	return 0
+}
+
+func (x *Scalars) GetBoolField() bool {
+	if x != nil {
+		return x.BoolField
+	}
+	return false
+}
+
+func (x *Scalars) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Scalars) GetUuidBytes() []byte {
+	if x != nil {
+		return x.UuidBytes
+	}
+	return nil
+}
+
+func (x *Scalars) GetUuid() string {
+	if x != nil {
+		return x.Uuid
+	}
+	return ""
+}
+
+func (x *Scalars) GetUuids() []string {
+	if x != nil {
+		return x.Uuids
+	}
+	return nil
+}
+
+func (x *Scalars) GetOptionalInt32() int32 {
+	if x != nil && x.OptionalInt32 != nil {
+		return *x.OptionalInt32
+	}
+	return 0
+}
+
+func (x *Scalars) GetOptionalString() string {
+	if x != nil && x.OptionalString != nil {
+		return *x.OptionalString
+	}
+	return ""
+}
+
+func (x *Scalars) GetInt32Map() map[int32]string {
+	if x != nil {
+		return x.Int32Map
+	}
+	return nil
+}
+
+func (x *Scalars) GetBoolMap() map[bool]int32 {
+	if x != nil {
+		return x.BoolMap
+	}
+	return nil
+}
+
+var File_gen_scalars_proto protoreflect.FileDescriptor
+
+var file_gen_scalars_proto_rawDesc = []byte{
+	0x0a, 0x11, 0x67, 0x65, 0x6e, 0x2f, 0x73, 0x63, 0x61, 0x6c, 0x61, 0x72, 0x73, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x12, 0x03, 0x67, 0x65, 0x6e, 0x1a, 0x18, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x5f,
+	0x62, 0x73, 0x6f, 0x6e, 0x2f, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x22, 0xf6, 0x08, 0x0a, 0x07, 0x53, 0x63, 0x61, 0x6c, 0x61, 0x72, 0x73, 0x12, 0x21,
+	0x0a, 0x0c, 0x75, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x75, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x46, 0x69, 0x65, 0x6c,
+	0x64, 0x12, 0x23, 0x0a, 0x0d, 0x66, 0x69, 0x78, 0x65, 0x64, 0x36, 0x34, 0x5f, 0x66, 0x69, 0x65,
+	0x6c, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x06, 0x52, 0x0c, 0x66, 0x69, 0x78, 0x65, 0x64, 0x36,
+	0x34, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x2d, 0x0a, 0x0e, 0x64, 0x65, 0x63, 0x69, 0x6d, 0x61,
+	0x6c, 0x5f, 0x75, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x42, 0x06,
+	0xea, 0x92, 0x19, 0x02, 0x28, 0x02, 0x52, 0x0d, 0x64, 0x65, 0x63, 0x69, 0x6d, 0x61, 0x6c, 0x55,
+	0x69, 0x6e, 0x74, 0x36, 0x34, 0x12, 0x27, 0x0a, 0x0b, 0x75, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x5f,
+	0x6c, 0x69, 0x73, 0x74, 0x18, 0x04, 0x20, 0x03, 0x28, 0x04, 0x42, 0x06, 0xea, 0x92, 0x19, 0x02,
+	0x28, 0x03, 0x52, 0x0a, 0x75, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x42,
+	0x0a, 0x0a, 0x75, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x5f, 0x6d, 0x61, 0x70, 0x18, 0x05, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x67, 0x65, 0x6e, 0x2e, 0x53, 0x63, 0x61, 0x6c, 0x61, 0x72, 0x73,
+	0x2e, 0x55, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x42,
+	0x06, 0xea, 0x92, 0x19, 0x02, 0x28, 0x03, 0x52, 0x09, 0x75, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x4d,
+	0x61, 0x70, 0x12, 0x20, 0x0a, 0x05, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x0a, 0x2e, 0x67, 0x65, 0x6e, 0x2e, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x52, 0x05, 0x63,
+	0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x22, 0x0a, 0x06, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x18, 0x07,
+	0x20, 0x03, 0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x67, 0x65, 0x6e, 0x2e, 0x43, 0x6f, 0x6c, 0x6f, 0x72,
+	0x52, 0x06, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x73, 0x12, 0x37, 0x0a, 0x09, 0x63, 0x6f, 0x6c, 0x6f,
+	0x72, 0x5f, 0x6d, 0x61, 0x70, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x65,
+	0x6e, 0x2e, 0x53, 0x63, 0x61, 0x6c, 0x61, 0x72, 0x73, 0x2e, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x4d,
+	0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x4d, 0x61,
+	0x70, 0x12, 0x1f, 0x0a, 0x0b, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x5f, 0x66, 0x69, 0x65, 0x6c, 0x64,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x46, 0x69, 0x65,
+	0x6c, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x5f, 0x66, 0x69, 0x65, 0x6c,
+	0x64, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x46, 0x69,
+	0x65, 0x6c, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x66, 0x6c, 0x6f, 0x61, 0x74, 0x5f, 0x66, 0x69, 0x65,
+	0x6c, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x02, 0x52, 0x0a, 0x66, 0x6c, 0x6f, 0x61, 0x74, 0x46,
+	0x69, 0x65, 0x6c, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x64, 0x6f, 0x75, 0x62, 0x6c, 0x65, 0x5f, 0x66,
+	0x69, 0x65, 0x6c, 0x64, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x64, 0x6f, 0x75, 0x62,
+	0x6c, 0x65, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x6f, 0x6f, 0x6c, 0x5f,
+	0x66, 0x69, 0x65, 0x6c, 0x64, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x62, 0x6f, 0x6f,
+	0x6c, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x0e,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x25, 0x0a, 0x0a, 0x75, 0x75,
+	0x69, 0x64, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0c, 0x42, 0x06,
+	0xea, 0x92, 0x19, 0x02, 0x30, 0x04, 0x52, 0x09, 0x75, 0x75, 0x69, 0x64, 0x42, 0x79, 0x74, 0x65,
+	0x73, 0x12, 0x1a, 0x0a, 0x04, 0x75, 0x75, 0x69, 0x64, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x42,
+	0x06, 0xea, 0x92, 0x19, 0x02, 0x38, 0x01, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x12, 0x1c, 0x0a,
+	0x05, 0x75, 0x75, 0x69, 0x64, 0x73, 0x18, 0x11, 0x20, 0x03, 0x28, 0x09, 0x42, 0x06, 0xea, 0x92,
+	0x19, 0x02, 0x38, 0x01, 0x52, 0x05, 0x75, 0x75, 0x69, 0x64, 0x73, 0x12, 0x2a, 0x0a, 0x0e, 0x6f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x5f, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x18, 0x12, 0x20,
+	0x01, 0x28, 0x05, 0x48, 0x00, 0x52, 0x0d, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x49,
+	0x6e, 0x74, 0x33, 0x32, 0x88, 0x01, 0x01, 0x12, 0x2c, 0x0a, 0x0f, 0x6f, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x61, 0x6c, 0x5f, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x18, 0x13, 0x20, 0x01, 0x28, 0x09,
+	0x48, 0x01, 0x52, 0x0e, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c, 0x53, 0x74, 0x72, 0x69,
+	0x6e, 0x67, 0x88, 0x01, 0x01, 0x12, 0x37, 0x0a, 0x09, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x5f, 0x6d,
+	0x61, 0x70, 0x18, 0x14, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x65, 0x6e, 0x2e, 0x53,
+	0x63, 0x61, 0x6c, 0x61, 0x72, 0x73, 0x2e, 0x49, 0x6e, 0x74, 0x33, 0x32, 0x4d, 0x61, 0x70, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x4d, 0x61, 0x70, 0x12, 0x34,
+	0x0a, 0x08, 0x62, 0x6f, 0x6f, 0x6c, 0x5f, 0x6d, 0x61, 0x70, 0x18, 0x15, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x67, 0x65, 0x6e, 0x2e, 0x53, 0x63, 0x61, 0x6c, 0x61, 0x72, 0x73, 0x2e, 0x42,
+	0x6f, 0x6f, 0x6c, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x62, 0x6f, 0x6f,
+	0x6c, 0x4d, 0x61, 0x70, 0x1a, 0x3c, 0x0a, 0x0e, 0x55, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x4d, 0x61,
+	0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
+	0x38, 0x01, 0x1a, 0x47, 0x0a, 0x0d, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x4d, 0x61, 0x70, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x20, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x67, 0x65, 0x6e, 0x2e, 0x43, 0x6f, 0x6c, 0x6f, 0x72,
+	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3b, 0x0a, 0x0d, 0x49,
+	0x6e, 0x74, 0x33, 0x32, 0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x3a, 0x0a, 0x0c, 0x42, 0x6f, 0x6f, 0x6c,
+	0x4d, 0x61, 0x70, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x42, 0x11, 0x0a, 0x0f, 0x5f, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x61,
+	0x6c, 0x5f, 0x69, 0x6e, 0x74, 0x33, 0x32, 0x42, 0x12, 0x0a, 0x10, 0x5f, 0x6f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x61, 0x6c, 0x5f, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x2a, 0x3e, 0x0a, 0x05, 0x43,
+	0x6f, 0x6c, 0x6f, 0x72, 0x12, 0x15, 0x0a, 0x11, 0x43, 0x4f, 0x4c, 0x4f, 0x52, 0x5f, 0x55, 0x4e,
+	0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x43,
+	0x4f, 0x4c, 0x4f, 0x52, 0x5f, 0x52, 0x45, 0x44, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b, 0x43, 0x4f,
+	0x4c, 0x4f, 0x52, 0x5f, 0x47, 0x52, 0x45, 0x45, 0x4e, 0x10, 0x02, 0x42, 0x2c, 0x5a, 0x2a, 0x62,
+	0x69, 0x74, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x2e, 0x6f, 0x72, 0x67, 0x2f, 0x65, 0x6e, 0x74,
+	0x72, 0x6c, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2d, 0x6d, 0x6f, 0x6e, 0x67,
+	0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x3b, 0x67, 0x65, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_gen_scalars_proto_rawDescOnce sync.Once
+	file_gen_scalars_proto_rawDescData = file_gen_scalars_proto_rawDesc
+)
+
+func file_gen_scalars_proto_rawDescGZIP() []byte {
+	file_gen_scalars_proto_rawDescOnce.Do(func() {
+		file_gen_scalars_proto_rawDescData = protoimpl.X.CompressGZIP(file_gen_scalars_proto_rawDescData)
+	})
+	return file_gen_scalars_proto_rawDescData
+}
+
+var file_gen_scalars_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_gen_scalars_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_gen_scalars_proto_goTypes = []interface{}{
+	(Color)(0),      // 0: gen.Color
+	(*Scalars)(nil), // 1: gen.Scalars
+	nil,             // 2: gen.Scalars.Uint64MapEntry
+	nil,             // 3: gen.Scalars.ColorMapEntry
+	nil,             // 4: gen.Scalars.Int32MapEntry
+	nil,             // 5: gen.Scalars.BoolMapEntry
+}
+var file_gen_scalars_proto_depIdxs = []int32{
+	2, // 0: gen.Scalars.uint64_map:type_name -> gen.Scalars.Uint64MapEntry
+	0, // 1: gen.Scalars.color:type_name -> gen.Color
+	0, // 2: gen.Scalars.colors:type_name -> gen.Color
+	3, // 3: gen.Scalars.color_map:type_name -> gen.Scalars.ColorMapEntry
+	4, // 4: gen.Scalars.int32_map:type_name -> gen.Scalars.Int32MapEntry
+	5, // 5: gen.Scalars.bool_map:type_name -> gen.Scalars.BoolMapEntry
+	0, // 6: gen.Scalars.ColorMapEntry.value:type_name -> gen.Color
+	7, // [7:7] is the sub-list for method output_type
+	7, // [7:7] is the sub-list for method input_type
+	7, // [7:7] is the sub-list for extension type_name
+	7, // [7:7] is the sub-list for extension extendee
+	0, // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_gen_scalars_proto_init() }
+func file_gen_scalars_proto_init() {
+	if File_gen_scalars_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_gen_scalars_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Scalars); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_gen_scalars_proto_msgTypes[0].OneofWrappers = []interface{}{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_gen_scalars_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_gen_scalars_proto_goTypes,
+		DependencyIndexes: file_gen_scalars_proto_depIdxs,
+		EnumInfos:         file_gen_scalars_proto_enumTypes,
+		MessageInfos:      file_gen_scalars_proto_msgTypes,
+	}.Build()
+	File_gen_scalars_proto = out.File
+	file_gen_scalars_proto_rawDesc = nil
+	file_gen_scalars_proto_goTypes = nil
+	file_gen_scalars_proto_depIdxs = nil
+}
@@ -4,12 +4,10 @@ go 1.16
 
 require (
 	bitbucket.org/entrlcom/genproto v0.0.0-20210823173404-b52fb66fb118
-	github.com/caarlos0/env/v6 v6.6.2
-	github.com/joho/godotenv v1.3.0
 	github.com/stretchr/testify v1.7.0
 	go.mongodb.org/mongo-driver v1.7.1
 	go.uber.org/atomic v1.9.0 // indirect
-	go.uber.org/multierr v1.7.0 // indirect
+	go.uber.org/multierr v1.7.0
 	go.uber.org/tools v0.0.0-20190618225709-2cfd321de3ee // indirect
 	go.uber.org/zap v1.19.0
 	google.golang.org/protobuf v1.27.1
@@ -0,0 +1,489 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.17.3
+// source: proto_bson/options.proto
+
+package protobson
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Uint64Mode задает, как хранить беззнаковые 64-битные числа, для которых в BSON
+// нет своего типа.
+type Uint64Mode int32
+
+const (
+	// Представление берется из настроек кодека.
+	Uint64Mode_UINT64_MODE_UNSPECIFIED Uint64Mode = 0
+	// int64 с тем же набором бит: значения больше MaxInt64 становятся
+	// отрицательными.
+	Uint64Mode_UINT64_MODE_INT64 Uint64Mode = 1
+	// Decimal128 - точное значение, доступное для сравнений и агрегаций.
+	Uint64Mode_UINT64_MODE_DECIMAL128 Uint64Mode = 2
+	// Десятичная строка.
+	Uint64Mode_UINT64_MODE_STRING Uint64Mode = 3
+)
+
+// Enum value maps for Uint64Mode.
+var (
+	Uint64Mode_name = map[int32]string{
+		0: "UINT64_MODE_UNSPECIFIED",
+		1: "UINT64_MODE_INT64",
+		2: "UINT64_MODE_DECIMAL128",
+		3: "UINT64_MODE_STRING",
+	}
+	Uint64Mode_value = map[string]int32{
+		"UINT64_MODE_UNSPECIFIED": 0,
+		"UINT64_MODE_INT64":       1,
+		"UINT64_MODE_DECIMAL128":  2,
+		"UINT64_MODE_STRING":      3,
+	}
+)
+
+func (x Uint64Mode) Enum() *Uint64Mode {
+	p := new(Uint64Mode)
+	*p = x
+	return p
+}
+
+func (x Uint64Mode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Uint64Mode) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_bson_options_proto_enumTypes[0].Descriptor()
+}
+
+func (Uint64Mode) Type() protoreflect.EnumType {
+	return &file_proto_bson_options_proto_enumTypes[0]
+}
+
+func (x Uint64Mode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Uint64Mode.Descriptor instead.
+func (Uint64Mode) EnumDescriptor() ([]byte, []int) {
+	return file_proto_bson_options_proto_rawDescGZIP(), []int{0}
+}
+
+// GeoShape - вид GeoJSON фигуры. Точкой может быть сообщение с double полями
+// latitude и longitude, как google.type.LatLng. Линия и полигон - сообщения с
+// единственным repeated полем таких точек; у полигона это внешний контур,
+// который должен быть замкнут.
+type GeoShape int32
+
+const (
+	GeoShape_GEO_SHAPE_UNSPECIFIED GeoShape = 0
+	GeoShape_GEO_SHAPE_POINT       GeoShape = 1
+	GeoShape_GEO_SHAPE_LINE_STRING GeoShape = 2
+	GeoShape_GEO_SHAPE_POLYGON     GeoShape = 3
+)
+
+// Enum value maps for GeoShape.
+var (
+	GeoShape_name = map[int32]string{
+		0: "GEO_SHAPE_UNSPECIFIED",
+		1: "GEO_SHAPE_POINT",
+		2: "GEO_SHAPE_LINE_STRING",
+		3: "GEO_SHAPE_POLYGON",
+	}
+	GeoShape_value = map[string]int32{
+		"GEO_SHAPE_UNSPECIFIED": 0,
+		"GEO_SHAPE_POINT":       1,
+		"GEO_SHAPE_LINE_STRING": 2,
+		"GEO_SHAPE_POLYGON":     3,
+	}
+)
+
+func (x GeoShape) Enum() *GeoShape {
+	p := new(GeoShape)
+	*p = x
+	return p
+}
+
+func (x GeoShape) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GeoShape) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_bson_options_proto_enumTypes[1].Descriptor()
+}
+
+func (GeoShape) Type() protoreflect.EnumType {
+	return &file_proto_bson_options_proto_enumTypes[1]
+}
+
+func (x GeoShape) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GeoShape.Descriptor instead.
+func (GeoShape) EnumDescriptor() ([]byte, []int) {
+	return file_proto_bson_options_proto_rawDescGZIP(), []int{1}
+}
+
+// FieldOptions описывает, как поле сообщения хранится в BSON документе.
+//
+// Пример:
+//
+//	message Account {
+//	  string id = 1 [(proto_bson.field) = {id: true}];
+//	  string display_name = 2 [(proto_bson.field) = {name: "name"}];
+//	  string password = 3 [(proto_bson.field) = {omit: true}];
+//	  Audit audit = 4 [(proto_bson.field) = {inline: true}];
+//	}
+type FieldOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Ключ поля в документе. Имеет приоритет над стратегией именования.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Поле не пишется в документ и не читается из него.
+	Omit bool `protobuf:"varint,2,opt,name=omit,proto3" json:"omit,omitempty"`
+	// Поля вложенного сообщения пишутся прямо в документ родителя, без
+	// отдельного поддокумента. Допустимо только для одиночных полей-сообщений.
+	Inline bool `protobuf:"varint,3,opt,name=inline,proto3" json:"inline,omitempty"`
+	// Поле хранится под ключом _id, т.е. является идентификатором документа.
+	// Строка из 24 hex символов в нижнем регистре сохраняется как ObjectID и при
+	// чтении превращается обратно в строку.
+	Id bool `protobuf:"varint,4,opt,name=id,proto3" json:"id,omitempty"`
+	// Представление uint64 и fixed64 значений поля. Имеет приоритет над
+	// настройками кодека. Для мап относится к значениям.
+	Uint64Mode Uint64Mode `protobuf:"varint,5,opt,name=uint64_mode,json=uint64Mode,proto3,enum=proto_bson.Uint64Mode" json:"uint64_mode,omitempty"`
+	// Подтип BSON Binary, с которым хранится bytes поле, например, 4 - UUID. По
+	// умолчанию - 0, т.е. произвольные данные. Допустимы значения 0-255.
+	BinarySubtype uint32 `protobuf:"varint,6,opt,name=binary_subtype,json=binarySubtype,proto3" json:"binary_subtype,omitempty"`
+	// Строковое поле хранит UUID: он сохраняется как Binary с подтипом 4 и при
+	// чтении превращается обратно в каноническую строку в нижнем регистре.
+	// Пустая строка хранится как есть.
+	Uuid bool `protobuf:"varint,7,opt,name=uuid,proto3" json:"uuid,omitempty"`
+}
+
+func (x *FieldOptions) Reset() {
+	*x = FieldOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_bson_options_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FieldOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldOptions) ProtoMessage() {}
+
+func (x *FieldOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_bson_options_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldOptions.ProtoReflect.Descriptor instead.
+func (*FieldOptions) Descriptor() ([]byte, []int) {
+	return file_proto_bson_options_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FieldOptions) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FieldOptions) GetOmit() bool {
+	if x != nil {
+		return x.Omit
+	}
+	return false
+}
+
+func (x *FieldOptions) GetInline() bool {
+	if x != nil {
+		return x.Inline
+	}
+	return false
+}
+
+func (x *FieldOptions) GetId() bool {
+	if x != nil {
+		return x.Id
+	}
+	return false
+}
+
+func (x *FieldOptions) GetUint64Mode() Uint64Mode {
+	if x != nil {
+		return x.Uint64Mode
+	}
+	return Uint64Mode_UINT64_MODE_UNSPECIFIED
+}
+
+func (x *FieldOptions) GetBinarySubtype() uint32 {
+	if x != nil {
+		return x.BinarySubtype
+	}
+	return 0
+}
+
+func (x *FieldOptions) GetUuid() bool {
+	if x != nil {
+		return x.Uuid
+	}
+	return false
+}
+
+// MessageOptions описывает, как сообщение хранится в BSON.
+//
+// Пример:
+//
+//	message Route {
+//	  option (proto_bson.message) = {geo_shape: GEO_SHAPE_LINE_STRING};
+//	  repeated google.type.LatLng points = 1;
+//	}
+type MessageOptions struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Сообщение хранится как GeoJSON фигура, пригодная для индекса 2dsphere.
+	GeoShape GeoShape `protobuf:"varint,1,opt,name=geo_shape,json=geoShape,proto3,enum=proto_bson.GeoShape" json:"geo_shape,omitempty"`
+}
+
+func (x *MessageOptions) Reset() {
+	*x = MessageOptions{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_bson_options_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MessageOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessageOptions) ProtoMessage() {}
+
+func (x *MessageOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_bson_options_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessageOptions.ProtoReflect.Descriptor instead.
+func (*MessageOptions) Descriptor() ([]byte, []int) {
+	return file_proto_bson_options_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *MessageOptions) GetGeoShape() GeoShape {
+	if x != nil {
+		return x.GeoShape
+	}
+	return GeoShape_GEO_SHAPE_UNSPECIFIED
+}
+
+var file_proto_bson_options_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.FieldOptions)(nil),
+		ExtensionType: (*FieldOptions)(nil),
+		Field:         51501,
+		Name:          "proto_bson.field",
+		Tag:           "bytes,51501,opt,name=field",
+		Filename:      "proto_bson/options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MessageOptions)(nil),
+		ExtensionType: (*MessageOptions)(nil),
+		Field:         51501,
+		Name:          "proto_bson.message",
+		Tag:           "bytes,51501,opt,name=message",
+		Filename:      "proto_bson/options.proto",
+	},
+}
+
+// Extension fields to descriptorpb.FieldOptions.
+var (
+	// optional proto_bson.FieldOptions field = 51501;
+	E_Field = &file_proto_bson_options_proto_extTypes[0]
+)
+
+// Extension fields to descriptorpb.MessageOptions.
+var (
+	// optional proto_bson.MessageOptions message = 51501;
+	E_Message = &file_proto_bson_options_proto_extTypes[1]
+)
+
+var File_proto_bson_options_proto protoreflect.FileDescriptor
+
+var file_proto_bson_options_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x5f, 0x62, 0x73, 0x6f, 0x6e, 0x2f, 0x6f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0a, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x5f, 0x62, 0x73, 0x6f, 0x6e, 0x1a, 0x20, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74,
+	0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xd2, 0x01, 0x0a, 0x0c, 0x46, 0x69, 0x65,
+	0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a,
+	0x04, 0x6f, 0x6d, 0x69, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x6f, 0x6d, 0x69,
+	0x74, 0x12, 0x16, 0x0a, 0x06, 0x69, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x06, 0x69, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x02, 0x69, 0x64, 0x12, 0x37, 0x0a, 0x0b, 0x75, 0x69, 0x6e,
+	0x74, 0x36, 0x34, 0x5f, 0x6d, 0x6f, 0x64, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x16,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x5f, 0x62, 0x73, 0x6f, 0x6e, 0x2e, 0x55, 0x69, 0x6e, 0x74,
+	0x36, 0x34, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x0a, 0x75, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x4d, 0x6f,
+	0x64, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x62, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x5f, 0x73, 0x75, 0x62,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x62, 0x69, 0x6e, 0x61,
+	0x72, 0x79, 0x53, 0x75, 0x62, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x75, 0x69,
+	0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x75, 0x75, 0x69, 0x64, 0x22, 0x43, 0x0a,
+	0x0e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12,
+	0x31, 0x0a, 0x09, 0x67, 0x65, 0x6f, 0x5f, 0x73, 0x68, 0x61, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x5f, 0x62, 0x73, 0x6f, 0x6e, 0x2e,
+	0x47, 0x65, 0x6f, 0x53, 0x68, 0x61, 0x70, 0x65, 0x52, 0x08, 0x67, 0x65, 0x6f, 0x53, 0x68, 0x61,
+	0x70, 0x65, 0x2a, 0x74, 0x0a, 0x0a, 0x55, 0x69, 0x6e, 0x74, 0x36, 0x34, 0x4d, 0x6f, 0x64, 0x65,
+	0x12, 0x1b, 0x0a, 0x17, 0x55, 0x49, 0x4e, 0x54, 0x36, 0x34, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f,
+	0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x15, 0x0a,
+	0x11, 0x55, 0x49, 0x4e, 0x54, 0x36, 0x34, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f, 0x49, 0x4e, 0x54,
+	0x36, 0x34, 0x10, 0x01, 0x12, 0x1a, 0x0a, 0x16, 0x55, 0x49, 0x4e, 0x54, 0x36, 0x34, 0x5f, 0x4d,
+	0x4f, 0x44, 0x45, 0x5f, 0x44, 0x45, 0x43, 0x49, 0x4d, 0x41, 0x4c, 0x31, 0x32, 0x38, 0x10, 0x02,
+	0x12, 0x16, 0x0a, 0x12, 0x55, 0x49, 0x4e, 0x54, 0x36, 0x34, 0x5f, 0x4d, 0x4f, 0x44, 0x45, 0x5f,
+	0x53, 0x54, 0x52, 0x49, 0x4e, 0x47, 0x10, 0x03, 0x2a, 0x6c, 0x0a, 0x08, 0x47, 0x65, 0x6f, 0x53,
+	0x68, 0x61, 0x70, 0x65, 0x12, 0x19, 0x0a, 0x15, 0x47, 0x45, 0x4f, 0x5f, 0x53, 0x48, 0x41, 0x50,
+	0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12,
+	0x13, 0x0a, 0x0f, 0x47, 0x45, 0x4f, 0x5f, 0x53, 0x48, 0x41, 0x50, 0x45, 0x5f, 0x50, 0x4f, 0x49,
+	0x4e, 0x54, 0x10, 0x01, 0x12, 0x19, 0x0a, 0x15, 0x47, 0x45, 0x4f, 0x5f, 0x53, 0x48, 0x41, 0x50,
+	0x45, 0x5f, 0x4c, 0x49, 0x4e, 0x45, 0x5f, 0x53, 0x54, 0x52, 0x49, 0x4e, 0x47, 0x10, 0x02, 0x12,
+	0x15, 0x0a, 0x11, 0x47, 0x45, 0x4f, 0x5f, 0x53, 0x48, 0x41, 0x50, 0x45, 0x5f, 0x50, 0x4f, 0x4c,
+	0x59, 0x47, 0x4f, 0x4e, 0x10, 0x03, 0x3a, 0x4f, 0x0a, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x12,
+	0x1d, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xad,
+	0x92, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x5f, 0x62,
+	0x73, 0x6f, 0x6e, 0x2e, 0x46, 0x69, 0x65, 0x6c, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x52, 0x05, 0x66, 0x69, 0x65, 0x6c, 0x64, 0x3a, 0x57, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x1f, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x4f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x18, 0xad, 0x92, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x5f, 0x62, 0x73, 0x6f, 0x6e, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x42, 0x39, 0x5a, 0x37, 0x62, 0x69, 0x74, 0x62, 0x75, 0x63, 0x6b, 0x65, 0x74, 0x2e, 0x6f, 0x72,
+	0x67, 0x2f, 0x65, 0x6e, 0x74, 0x72, 0x6c, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x2d, 0x6d, 0x6f, 0x6e, 0x67, 0x6f, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x5f, 0x62, 0x73, 0x6f,
+	0x6e, 0x3b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x73, 0x6f, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_bson_options_proto_rawDescOnce sync.Once
+	file_proto_bson_options_proto_rawDescData = file_proto_bson_options_proto_rawDesc
+)
+
+func file_proto_bson_options_proto_rawDescGZIP() []byte {
+	file_proto_bson_options_proto_rawDescOnce.Do(func() {
+		file_proto_bson_options_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_bson_options_proto_rawDescData)
+	})
+	return file_proto_bson_options_proto_rawDescData
+}
+
+var file_proto_bson_options_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_proto_bson_options_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_bson_options_proto_goTypes = []interface{}{
+	(Uint64Mode)(0),                     // 0: proto_bson.Uint64Mode
+	(GeoShape)(0),                       // 1: proto_bson.GeoShape
+	(*FieldOptions)(nil),                // 2: proto_bson.FieldOptions
+	(*MessageOptions)(nil),              // 3: proto_bson.MessageOptions
+	(*descriptorpb.FieldOptions)(nil),   // 4: google.protobuf.FieldOptions
+	(*descriptorpb.MessageOptions)(nil), // 5: google.protobuf.MessageOptions
+}
+var file_proto_bson_options_proto_depIdxs = []int32{
+	0, // 0: proto_bson.FieldOptions.uint64_mode:type_name -> proto_bson.Uint64Mode
+	1, // 1: proto_bson.MessageOptions.geo_shape:type_name -> proto_bson.GeoShape
+	4, // 2: proto_bson.field:extendee -> google.protobuf.FieldOptions
+	5, // 3: proto_bson.message:extendee -> google.protobuf.MessageOptions
+	2, // 4: proto_bson.field:type_name -> proto_bson.FieldOptions
+	3, // 5: proto_bson.message:type_name -> proto_bson.MessageOptions
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	4, // [4:6] is the sub-list for extension type_name
+	2, // [2:4] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_proto_bson_options_proto_init() }
+func file_proto_bson_options_proto_init() {
+	if File_proto_bson_options_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_proto_bson_options_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FieldOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_bson_options_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MessageOptions); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_bson_options_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   2,
+			NumExtensions: 2,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_bson_options_proto_goTypes,
+		DependencyIndexes: file_proto_bson_options_proto_depIdxs,
+		EnumInfos:         file_proto_bson_options_proto_enumTypes,
+		MessageInfos:      file_proto_bson_options_proto_msgTypes,
+		ExtensionInfos:    file_proto_bson_options_proto_extTypes,
+	}.Build()
+	File_proto_bson_options_proto = out.File
+	file_proto_bson_options_proto_rawDesc = nil
+	file_proto_bson_options_proto_goTypes = nil
+	file_proto_bson_options_proto_depIdxs = nil
+}